@@ -0,0 +1,60 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"sort"
+)
+
+// Closure returns roots and the definitions they refer to directly or indirectly
+// through their fields, sorted by key
+func (s *Definitions) Closure(roots []*Definition) SortDefinitionsByName {
+	found := map[string]*Definition{}
+	queue := append([]*Definition{}, roots...)
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		if _, ok := found[d.Key()]; ok {
+			continue
+		}
+		found[d.Key()] = d
+		queue = append(queue, s.getReferences(d)...)
+	}
+
+	closure := SortDefinitionsByName{}
+	for _, d := range found {
+		closure = append(closure, d)
+	}
+	sort.Slice(closure, func(i, j int) bool { return closure[i].Key() < closure[j].Key() })
+	return closure
+}
+
+// GetOperationsOf returns the operations on the given definitions, sorted by id
+func (c *Config) GetOperationsOf(defs []*Definition) []*Operation {
+	selected := map[*Definition]bool{}
+	for _, d := range defs {
+		selected[d] = true
+	}
+	ops := []*Operation{}
+	for _, o := range c.Operations {
+		if o.Definition != nil && selected[o.Definition] {
+			ops = append(ops, o)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	return ops
+}
@@ -93,6 +93,10 @@ func NewConfig() *Config {
 
 	config.CleanUp()
 
+	config.classifyFields()
+
+	config.markDeprecations()
+
 	// Prune anything that shouldn't be in the ToC
 	if *UseTags {
 		categories := []ResourceCategory{}
@@ -490,6 +494,9 @@ func (c *Config) setOperation(match, namespace string, ot *OperationType, oc *Op
 		}
 		o.Type = *ot
 		o.Definition = d
+		if namespace != "" {
+			d.Namespaced = true
+		}
 		o.initExample(c)
 		oc.Operations = append(oc.Operations, o)
 
@@ -0,0 +1,391 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Confidence rates how reliably constraints were recognized in a description
+type Confidence int
+
+const (
+	ConfidenceNone Confidence = iota
+	// Loosely worded or conditional statements, e.g. "default is admin"
+	ConfidenceLow
+	// Statements in a list or in the middle of a sentence, e.g. "in the range 1-100"
+	ConfidenceMedium
+	// Statements in the usual API conventions wording, e.g. "Defaults to 1."
+	ConfidenceHigh
+)
+
+var confidenceNames = []string{"none", "low", "medium", "high"}
+
+func (c Confidence) String() string {
+	return confidenceNames[c]
+}
+
+func ParseConfidence(s string) (Confidence, error) {
+	for i, name := range confidenceNames {
+		if strings.EqualFold(s, name) {
+			return Confidence(i), nil
+		}
+	}
+	return ConfidenceNone, fmt.Errorf("Unknown confidence %q, expected one of %v", s, confidenceNames)
+}
+
+// FieldConstraints are the default, allowed values and bounds stated in the description of a field,
+// each rated with the confidence of the statement it was found in
+type FieldConstraints struct {
+	// Default is the value used when the field is not set, typed after the field
+	Default           interface{}
+	DefaultConfidence Confidence
+	// AllowedValues are the values the field accepts
+	AllowedValues           []string
+	AllowedValuesConfidence Confidence
+	// Minimum and Maximum are inclusive bounds of integer fields,
+	// BoundsConfidence is the lower confidence of the two
+	Minimum          *int64
+	Maximum          *int64
+	BoundsConfidence Confidence
+}
+
+func (c FieldConstraints) IsEmpty() bool {
+	return c.Default == nil && len(c.AllowedValues) == 0 && c.Minimum == nil && c.Maximum == nil
+}
+
+// AtLeast returns the constraints rated at least min, none for ConfidenceNone
+func (c FieldConstraints) AtLeast(min Confidence) FieldConstraints {
+	if min == ConfidenceNone {
+		return FieldConstraints{}
+	}
+	if c.DefaultConfidence < min {
+		c.Default, c.DefaultConfidence = nil, ConfidenceNone
+	}
+	if c.AllowedValuesConfidence < min {
+		c.AllowedValues, c.AllowedValuesConfidence = nil, ConfidenceNone
+	}
+	if c.BoundsConfidence < min {
+		c.Minimum, c.Maximum, c.BoundsConfidence = nil, nil, ConfidenceNone
+	}
+	return c
+}
+
+func (c *FieldConstraints) foundBound(confidence Confidence) {
+	if c.BoundsConfidence == ConfidenceNone || confidence < c.BoundsConfidence {
+		c.BoundsConfidence = confidence
+	}
+}
+
+var (
+	defaultPattern = regexp.MustCompile(
+		`\b([Dd]efaults?) (to|is) ("[^"]*"|'[^']*'|[^\s,;()]+)\s*(\S*)`)
+	listedDefaultPattern = regexp.MustCompile(`"([A-Za-z]\w*)" \(default\)`)
+	allowedPattern       = regexp.MustCompile(
+		`(^|\.\s+)?([Mm]ust be one of|[Vv]alid values are|[Pp]ossible values are|[Cc]an be one of|[Oo]ne of):?\s+([^.;]+)`)
+	bulletPattern = regexp.MustCompile(`(?:^|\s)[-*] "?([A-Za-z]\w*)"?(?: \(default\))?:`)
+	wordPattern   = regexp.MustCompile(`^[A-Za-z0-9*_-]+$`)
+
+	nonNegativePattern = regexp.MustCompile(`(must be )?non-negative`)
+	positivePattern    = regexp.MustCompile(`[Mm]ust be (a )?positive|[Mm]ust be greater than (zero|0)\b`)
+	minimumPattern     = regexp.MustCompile(`[Mm]inimum value is (\d+|zero)|greater than or equal to (\d+|zero)`)
+	maximumPattern     = regexp.MustCompile(`[Mm]aximum value is (\d+)|less than or equal to (\d+)`)
+	betweenPattern     = regexp.MustCompile(`(must be )?between (\d+) and (\d+)|in the range (\d+)(?:-| to )(\d+)|ranged in \[(\d+),\s*(\d+)\]`)
+	exclusivePattern   = regexp.MustCompile(`(\d+) < x < (\d+)`)
+)
+
+// MineConstraints extracts the constraints stated in a field description.
+// Only fields of primitive types are considered.
+func MineConstraints(fieldType, description string) FieldConstraints {
+	c := FieldConstraints{}
+	switch fieldType {
+	case "string", "integer", "number", "boolean":
+	default:
+		return c
+	}
+	mineDefault(&c, fieldType, description)
+	if fieldType == "string" {
+		mineAllowedValues(&c, description)
+	}
+	if fieldType == "integer" {
+		mineBounds(&c, description)
+	}
+	return c
+}
+
+// AddSchemaConstraints sets the default, enum and the bounds of integers stated in the schema,
+// replacing those found in the description. Bounds found in the description are dropped if the
+// schema states either bound.
+func AddSchemaConstraints(c *FieldConstraints, schema spec.Schema) {
+	if schema.Default != nil {
+		c.Default, c.DefaultConfidence = schema.Default, ConfidenceHigh
+	}
+	if len(schema.Enum) > 0 {
+		c.AllowedValues = []string{}
+		for _, v := range schema.Enum {
+			c.AllowedValues = append(c.AllowedValues, fmt.Sprint(v))
+		}
+		c.AllowedValuesConfidence = ConfidenceHigh
+	}
+	if !schema.Type.Contains("integer") || (schema.Minimum == nil && schema.Maximum == nil) {
+		return
+	}
+	c.Minimum, c.Maximum, c.BoundsConfidence = nil, nil, ConfidenceNone
+	if schema.Minimum != nil {
+		min := int64(math.Ceil(*schema.Minimum))
+		if schema.ExclusiveMinimum && float64(min) == *schema.Minimum {
+			min++
+		}
+		c.Minimum = &min
+		c.foundBound(ConfidenceHigh)
+	}
+	if schema.Maximum != nil {
+		max := int64(math.Floor(*schema.Maximum))
+		if schema.ExclusiveMaximum && float64(max) == *schema.Maximum {
+			max--
+		}
+		c.Maximum = &max
+		c.foundBound(ConfidenceHigh)
+	}
+}
+
+func mineDefault(c *FieldConstraints, fieldType, description string) {
+	for _, m := range defaultPattern.FindAllStringSubmatch(description, -1) {
+		word, verb, raw, next := m[1], m[2], m[3], m[4]
+		quoted := strings.HasPrefix(raw, "\"") || strings.HasPrefix(raw, "'")
+		value := strings.TrimRight(raw, ".")
+		if quoted {
+			// skip quotes that are not closed, e.g. a stray "'."
+			if len(value) < 2 || value[len(value)-1] != value[0] {
+				continue
+			}
+			value = value[1 : len(value)-1]
+		}
+
+		confidence := ConfidenceHigh
+		if word != "Defaults" || verb != "to" {
+			confidence = ConfidenceMedium
+		}
+		if !strings.HasSuffix(raw, ".") && next != "" && !strings.Contains(".;,(", next[:1]) {
+			switch strings.TrimRight(next, ".,;") {
+			case "second", "seconds":
+			case "if", "when", "unless", "for", "on", "in", "otherwise":
+				// conditional defaults, e.g. "Defaults to Always if :latest tag is specified"
+				confidence = ConfidenceLow
+			default:
+				// integers followed by a unit other than seconds, e.g. "defaults to 1 hour"
+				if fieldType == "integer" {
+					continue
+				}
+				confidence--
+			}
+		}
+
+		typed, ok := typeDefault(fieldType, value, quoted, &confidence)
+		if !ok {
+			continue
+		}
+		c.Default, c.DefaultConfidence = typed, confidence
+		return
+	}
+	if fieldType == "string" {
+		if m := listedDefaultPattern.FindStringSubmatch(description); m != nil {
+			c.Default, c.DefaultConfidence = m[1], ConfidenceMedium
+		}
+	}
+}
+
+func typeDefault(fieldType, value string, quoted bool, confidence *Confidence) (interface{}, bool) {
+	switch fieldType {
+	case "integer":
+		// e.g. "Defaults to 600s" or "Defaults to 30 seconds", base 0 reads file modes like 0644
+		i, err := strconv.ParseInt(strings.TrimSuffix(value, "s"), 0, 64)
+		return i, err == nil
+	case "number":
+		f, err := strconv.ParseFloat(value, 64)
+		return f, err == nil
+	case "boolean":
+		b, err := strconv.ParseBool(strings.ToLower(value))
+		return b, err == nil
+	}
+	if quoted {
+		// templates like "`kind`List" are no values
+		return value, !strings.Contains(value, "`")
+	}
+	if !wordPattern.MatchString(value) {
+		return nil, false
+	}
+	// lower case words are mostly prose, e.g. "Defaults to the empty string"
+	if strings.ToLower(value[:1]) == value[:1] && (value[0] < '0' || value[0] > '9') {
+		switch value {
+		case "a", "an", "the", "empty", "no", "none", "nil", "null", "unset", "false", "true":
+			return nil, false
+		}
+		*confidence = ConfidenceLow
+	}
+	return value, true
+}
+
+func mineAllowedValues(c *FieldConstraints, description string) {
+	for _, m := range allowedPattern.FindAllStringSubmatchIndex(description, -1) {
+		sentenceStart := m[2] >= 0 || m[4] == 0
+		trigger := description[m[4]:m[5]]
+		list := description[m[6]:m[7]]
+
+		// bullet lists, e.g. `Valid values are: - "Allow" (default): allows ...; - "Forbid": ...`
+		rest := description[m[6]:]
+		if strings.HasPrefix(rest, "- ") || strings.HasPrefix(rest, "* ") || strings.HasPrefix(rest, "the following values:") {
+			values := []string{}
+			for _, b := range bulletPattern.FindAllStringSubmatch(rest, -1) {
+				values = append(values, b[1])
+			}
+			if len(values) > 1 {
+				c.AllowedValues, c.AllowedValuesConfidence = values, ConfidenceMedium
+				return
+			}
+			continue
+		}
+
+		values, ok := parseValueList(list)
+		if !ok {
+			continue
+		}
+		confidence := ConfidenceHigh
+		if !sentenceStart || strings.HasPrefix(strings.ToLower(trigger), "can be") {
+			confidence = ConfidenceMedium
+		}
+		c.AllowedValues, c.AllowedValuesConfidence = values, confidence
+		return
+	}
+}
+
+// parseValueList parses enumerations like `Always, OnFailure, Never` or `"Object", "Pods" or "Resource", each ...`
+func parseValueList(list string) ([]string, bool) {
+	values := []string{}
+	final := false
+	for _, piece := range strings.Split(list, ",") {
+		piece = strings.TrimSpace(piece)
+		items := []string{piece}
+		for _, conj := range []string{"or ", "and "} {
+			if strings.HasPrefix(piece, conj) {
+				items = []string{strings.TrimPrefix(piece, conj)}
+				final = true
+			} else if i := strings.Index(piece, " "+conj); i >= 0 {
+				items = []string{piece[:i], piece[i+len(conj)+1:]}
+				final = true
+			}
+		}
+		for i, item := range items {
+			value, rest, ok := parseValue(item)
+			if !ok {
+				return nil, false
+			}
+			// trailing prose is only accepted after the last item
+			if rest != "" && !(final && i == len(items)-1) {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+		if final {
+			break
+		}
+	}
+	return values, len(values) > 1
+}
+
+func parseValue(item string) (string, string, bool) {
+	for _, q := range []string{"\"", "'"} {
+		if strings.HasPrefix(item, q) {
+			end := strings.Index(item[1:], q)
+			if end < 0 {
+				return "", "", false
+			}
+			return item[1 : end+1], strings.TrimSpace(item[end+2:]), true
+		}
+	}
+	word := strings.SplitN(item, " ", 2)
+	if !wordPattern.MatchString(word[0]) || strings.ToUpper(word[0][:1]) != word[0][:1] {
+		return "", "", false
+	}
+	rest := ""
+	if len(word) > 1 {
+		rest = word[1]
+	}
+	return word[0], rest, true
+}
+
+func mineBounds(c *FieldConstraints, description string) {
+	setMinimum := func(v int64, confidence Confidence) {
+		c.Minimum = &v
+		c.foundBound(confidence)
+	}
+	setMaximum := func(v int64, confidence Confidence) {
+		c.Maximum = &v
+		c.foundBound(confidence)
+	}
+
+	if m := exclusivePattern.FindStringSubmatch(description); m != nil {
+		min, _ := strconv.ParseInt(m[1], 10, 64)
+		max, _ := strconv.ParseInt(m[2], 10, 64)
+		setMinimum(min+1, ConfidenceHigh)
+		setMaximum(max-1, ConfidenceHigh)
+		return
+	}
+	if m := betweenPattern.FindStringSubmatch(description); m != nil {
+		confidence := ConfidenceMedium
+		if m[1] != "" {
+			confidence = ConfidenceHigh
+		}
+		bounds := []string{}
+		for _, b := range m[2:] {
+			if b != "" {
+				bounds = append(bounds, b)
+			}
+		}
+		// base 0 to read file modes like 0777 as octal
+		min, err1 := strconv.ParseInt(bounds[0], 0, 64)
+		max, err2 := strconv.ParseInt(bounds[1], 0, 64)
+		if err1 == nil && err2 == nil {
+			setMinimum(min, confidence)
+			setMaximum(max, confidence)
+		}
+		return
+	}
+	if m := nonNegativePattern.FindStringSubmatch(description); m != nil {
+		confidence := ConfidenceMedium
+		if m[1] != "" || strings.Contains(description, "Value must be non-negative") {
+			confidence = ConfidenceHigh
+		}
+		setMinimum(0, confidence)
+	} else if positivePattern.MatchString(description) {
+		setMinimum(1, ConfidenceHigh)
+	}
+	if m := minimumPattern.FindStringSubmatch(description); m != nil {
+		v, _ := strconv.ParseInt(strings.Replace(m[1]+m[2], "zero", "0", 1), 10, 64)
+		setMinimum(v, ConfidenceHigh)
+	}
+	if m := maximumPattern.FindStringSubmatch(description); m != nil {
+		v, _ := strconv.ParseInt(m[1]+m[2], 10, 64)
+		setMaximum(v, ConfidenceHigh)
+	}
+}
@@ -191,11 +191,16 @@ func (s *Definitions) InitializeFields(d *Definition) {
 	for fieldName, property := range d.schema.Properties {
 		des := strings.Replace(property.Description, "\n", " ", -1)
 		f := &Field{
-			Name:        fieldName,
-			Type:        GetTypeName(property),
-			Description: EscapeAsterisks(des),
-			Required:    containsRequiredField(d.RequiredFields, fieldName),
+			Name:           fieldName,
+			Type:           GetTypeName(property),
+			Description:    EscapeAsterisks(des),
+			Required:       containsRequiredField(d.RequiredFields, fieldName),
+			SchemaRequired: containsRequiredField(d.schema.Required, fieldName),
+			Format:         property.Format,
+			Pattern:        property.Pattern,
+			Constraints:    MineConstraints(GetTypeName(property), des),
 		}
+		AddSchemaConstraints(&f.Constraints, property)
 		if len(property.Extensions) > 0 {
 			if ps, ok := property.Extensions.GetString(patchStrategyKey); ok {
 				f.PatchStrategy = ps
@@ -277,6 +282,11 @@ func (d *Definition) Description() string {
 	return EscapeAsterisks(d.schema.Description)
 }
 
+// Schema returns the open-api schema the definition was loaded from
+func (d *Definition) Schema() spec.Schema {
+	return d.schema
+}
+
 func (d *Definition) GetResourceName() string {
 	if len(d.Resource) > 0 {
 		return d.Resource
@@ -313,6 +323,37 @@ func (d *Definition) GetSamples() []ExampleText {
 	return r
 }
 
+// GroupVersion returns the apiVersion of objects of this definition, e.g. apps/v1
+func (d *Definition) GroupVersion() string {
+	if d.Group == "core" || len(d.GroupFullName) == 0 {
+		return d.Version.String()
+	}
+	return fmt.Sprintf("%s/%s", d.GroupFullName, d.Version)
+}
+
+// GetFieldByPath looks up a field by its dotted path, e.g. spec.template.metadata
+func (d *Definition) GetFieldByPath(path string) (*Field, bool) {
+	def := d
+	var found *Field
+	for _, name := range strings.Split(path, ".") {
+		if def == nil {
+			return nil, false
+		}
+		found = nil
+		for _, f := range def.Fields {
+			if f.Name == name {
+				found = f
+				break
+			}
+		}
+		if found == nil {
+			return nil, false
+		}
+		def = found.Definition
+	}
+	return found, true
+}
+
 func (d *Definition) IsWrapper() bool {
 	return len(d.Fields) == 0
 }
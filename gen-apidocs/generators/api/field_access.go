@@ -0,0 +1,91 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// FieldAccess classifies fields by who sets them
+type FieldAccess string
+
+const (
+	FieldAccessSettable        FieldAccess = "settable"
+	FieldAccessReadOnly        FieldAccess = "read-only"
+	FieldAccessServerPopulated FieldAccess = "server-populated"
+)
+
+// Fields whose descriptions do not tell they are not settable
+var DefaultFieldAccess = map[string]FieldAccess{
+	"ObjectMeta.managedFields": FieldAccessServerPopulated,
+}
+
+var (
+	serverPopulatedPattern = regexp.MustCompile(
+		`Populated by the (Kubernetes )?system|is not directly settable|populated by the apiserver`)
+	readOnlyPattern = regexp.MustCompile(`(^|\.\s+)Read-only\.`)
+)
+
+// IsSettable is true for fields users may set, fields without classification are settable
+func (a FieldAccess) IsSettable() bool {
+	return a == FieldAccessSettable || a == ""
+}
+
+// ClassifyFieldAccess tells from the description whether a field is settable
+func ClassifyFieldAccess(description string) FieldAccess {
+	switch {
+	case serverPopulatedPattern.MatchString(description):
+		return FieldAccessServerPopulated
+	case readOnlyPattern.MatchString(description):
+		return FieldAccessReadOnly
+	}
+	return FieldAccessSettable
+}
+
+// classifyFields sets the access of all fields from their descriptions, DefaultFieldAccess
+// and the field_access of the config
+func (c *Config) classifyFields() {
+	for key, access := range c.FieldAccess {
+		switch access {
+		case FieldAccessSettable, FieldAccessReadOnly, FieldAccessServerPopulated:
+		default:
+			panic(fmt.Sprintf("Unknown field access %s of %s, expected %s, %s or %s", access, key,
+				FieldAccessSettable, FieldAccessReadOnly, FieldAccessServerPopulated))
+		}
+	}
+
+	for _, d := range c.Definitions.All {
+		_, hasKind := d.GetFieldByPath("kind")
+		_, hasMetadata := d.GetFieldByPath("metadata")
+		isObject := hasKind && hasMetadata
+		for _, field := range d.Fields {
+			field.Access = ClassifyFieldAccess(field.Description)
+			// the status of objects is written by their controllers
+			if isObject && field.Name == "status" {
+				field.Access = FieldAccessServerPopulated
+			}
+			key := d.Name + "." + field.Name
+			if access, ok := DefaultFieldAccess[key]; ok {
+				field.Access = access
+			}
+			if access, ok := c.FieldAccess[key]; ok {
+				field.Access = access
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var HistoryReleases = flag.String("history-releases", "",
+	"Comma separated releases whose specs are scanned for the release definitions and fields appeared, were deprecated or removed in, "+
+		"e.g. 1.17,1.18,1.19. Defaults to the versioned config directories with a swagger.json, none for no history.")
+
+// ReleaseHistory tells in which of the scanned releases a definition or field first appeared,
+// was first deprecated and was removed. Since and DeprecatedIn are empty for those found, or
+// already deprecated, in the oldest release.
+type ReleaseHistory struct {
+	Since        string
+	DeprecatedIn string
+	RemovedIn    string
+}
+
+func (h ReleaseHistory) IsEmpty() bool {
+	return h.Since == "" && h.DeprecatedIn == "" && h.RemovedIn == ""
+}
+
+// historySpec is the part of swagger.json the history is built from
+type historySpec struct {
+	Definitions map[string]struct {
+		Description string
+		Properties  map[string]struct {
+			Description string
+		}
+	}
+}
+
+// historyEntry is the first, last and first deprecated release index of a definition or field
+type historyEntry struct {
+	first, last, deprecated int
+}
+
+// LoadHistory sets the release history of the definitions and fields from the scanned specs,
+// once for the outputs showing it
+func (c *Config) LoadHistory() {
+	if c.historyLoaded {
+		return
+	}
+	c.historyLoaded = true
+	releases := GetHistoryReleases()
+	if len(releases) < 2 {
+		return
+	}
+
+	entries := map[string]*historyEntry{}
+	see := func(key string, release int, deprecated bool) {
+		e, ok := entries[key]
+		if !ok {
+			e = &historyEntry{first: release, deprecated: -1}
+			entries[key] = e
+		}
+		e.last = release
+		if deprecated && e.deprecated < 0 {
+			e.deprecated = release
+		}
+	}
+	for i, release := range releases {
+		spec := loadHistorySpec(release)
+		for name, d := range spec.Definitions {
+			see(name, i, IsDeprecated(d.Description))
+			for field, p := range d.Properties {
+				see(name+"."+field, i, IsDeprecated(p.Description))
+			}
+		}
+	}
+
+	getHistory := func(key string) ReleaseHistory {
+		h := ReleaseHistory{}
+		e, ok := entries[key]
+		if !ok {
+			return h
+		}
+		if e.first > 0 {
+			h.Since = releases[e.first]
+		}
+		if e.deprecated > 0 {
+			h.DeprecatedIn = releases[e.deprecated]
+		}
+		if e.last < len(releases)-1 {
+			h.RemovedIn = releases[e.last+1]
+		}
+		return h
+	}
+	for _, d := range c.Definitions.All {
+		d.History = getHistory(d.OpenApiName)
+		for _, field := range d.Fields {
+			field.History = getHistory(d.OpenApiName + "." + field.Name)
+		}
+	}
+}
+
+// GetHistoryReleases returns the releases of --history-releases sorted by version, or those of
+// the versioned config directories
+func GetHistoryReleases() []string {
+	releases := []string{}
+	switch *HistoryReleases {
+	case "none":
+		return releases
+	case "":
+		dirs, _ := filepath.Glob(filepath.Join(ConfigDir, "v*_*", "swagger.json"))
+		for _, fn := range dirs {
+			dir := filepath.Base(filepath.Dir(fn))
+			releases = append(releases, strings.Replace(strings.TrimPrefix(dir, "v"), "_", ".", -1))
+		}
+	default:
+		for _, r := range strings.Split(*HistoryReleases, ",") {
+			if r = strings.TrimSpace(r); r != "" {
+				releases = append(releases, r)
+			}
+		}
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return CompareReleases(releases[i], releases[j]) < 0
+	})
+	return releases
+}
+
+// CompareReleases compares releases like 1.9 and 1.18 by their numbers
+func CompareReleases(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, errA := strconv.Atoi(as[i])
+		bn, errB := strconv.Atoi(bs[i])
+		if errA != nil || errB != nil {
+			return strings.Compare(a, b)
+		}
+		if an != bn {
+			return an - bn
+		}
+	}
+	return len(as) - len(bs)
+}
+
+func loadHistorySpec(release string) *historySpec {
+	dir := "v" + strings.Replace(release, ".", "_", -1)
+	fn := filepath.Join(ConfigDir, dir, "swagger.json")
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		fmt.Printf("Could not read the spec of release %s: %v\n", release, err)
+		os.Exit(1)
+	}
+	spec := &historySpec{}
+	if err := json.Unmarshal(b, spec); err != nil {
+		panic(fmt.Sprintf("Could not parse %s: %v", fn, err))
+	}
+	return spec
+}
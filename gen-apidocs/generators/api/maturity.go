@@ -0,0 +1,84 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Maturity is the stability level of an API version or a field
+type Maturity string
+
+const (
+	MaturityAlpha  Maturity = "alpha"
+	MaturityBeta   Maturity = "beta"
+	MaturityStable Maturity = "stable"
+)
+
+var (
+	fieldMaturityPattern = regexp.MustCompile(
+		`(?i)\b(?:is an?|is) (alpha|beta)(?:-level|\b)|\((alpha|beta) feature\)`)
+	deprecatedPattern = regexp.MustCompile(
+		`(^|[.:]\s+)(Deprecated|DEPRECATED)\b|\b(Deprecated|deprecated) in v?\d|\b(is|are|now) deprecated\b`)
+)
+
+// Maturity returns alpha or beta for versions like v1alpha1 and v2beta2, stable otherwise
+func (a ApiVersion) Maturity() Maturity {
+	switch {
+	case strings.Contains(string(a), string(MaturityAlpha)):
+		return MaturityAlpha
+	case strings.Contains(string(a), string(MaturityBeta)):
+		return MaturityBeta
+	}
+	return MaturityStable
+}
+
+// IsPreRelease is true for alpha and beta
+func (m Maturity) IsPreRelease() bool {
+	return m == MaturityAlpha || m == MaturityBeta
+}
+
+// Maturity of the definition from its api version
+func (d *Definition) Maturity() Maturity {
+	return d.Version.Maturity()
+}
+
+// ClassifyFieldMaturity tells from the description whether a field is an alpha or beta feature
+func ClassifyFieldMaturity(description string) Maturity {
+	m := fieldMaturityPattern.FindStringSubmatch(description)
+	if m == nil {
+		return MaturityStable
+	}
+	return Maturity(strings.ToLower(m[1] + m[2]))
+}
+
+// IsDeprecated tells whether a description states the type or field is deprecated
+func IsDeprecated(description string) bool {
+	return deprecatedPattern.MatchString(description)
+}
+
+// markDeprecations sets the maturity of fields and the deprecation of definitions and fields
+func (c *Config) markDeprecations() {
+	for _, d := range c.Definitions.All {
+		d.Deprecated = IsDeprecated(d.RawDescription)
+		for _, field := range d.Fields {
+			field.Maturity = ClassifyFieldMaturity(field.Description)
+			field.Deprecated = IsDeprecated(field.Description) || strings.HasPrefix(field.Name, "deprecated")
+		}
+	}
+}
@@ -150,6 +150,7 @@ func LoadDefinitions(specs []*loads.Document, s *Definitions) {
 
 			d := &Definition{
 				schema:        	spec,
+				OpenApiName:   	name,
 				Name:          	kind,
 				Version:       	ApiVersion(version),
 				Kind:          	ApiKind(kind),
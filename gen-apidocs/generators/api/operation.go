@@ -72,6 +72,11 @@ func (o *Operation) Description() string {
 	return o.op.Description
 }
 
+// Parameters returns the parameters of the path followed by those of the operation as stated in the spec
+func (o *Operation) Parameters() []spec.Parameter {
+	return append(append([]spec.Parameter{}, o.item.Parameters...), o.op.Parameters...)
+}
+
 func (a HttpResponses) Len() int           { return len(a) }
 func (a HttpResponses) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
 func (a HttpResponses) Less(i, j int) bool { return a[i].Code < a[j].Code }
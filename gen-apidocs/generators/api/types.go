@@ -151,6 +151,8 @@ func (a SortDefinitionsByVersion) Less(i, j int) bool {
 type Definition struct {
 	// open-api schema for the definition
 	schema spec.Schema
+	// Name of the definition in the open-api spec (e.g. io.k8s.api.apps.v1.Deployment)
+	OpenApiName string
 	// Display name of the definition (e.g. Deployment)
 	Name      string
 	Group     ApiGroup
@@ -171,6 +173,15 @@ type Definition struct {
 	FoundInField     bool
 	FoundInOperation bool
 
+	// Namespaced is true if the definition is operated within a namespace
+	Namespaced bool
+
+	// Deprecated is true if the description states the definition is deprecated
+	Deprecated bool
+
+	// Releases the definition appeared, was deprecated or removed in
+	History ReleaseHistory
+
 	// Inline is a list of definitions that should appear inlined with this one in the documentations
 	Inline SortDefinitionsByName
 
@@ -219,6 +230,12 @@ type Config struct {
 	// Includes only following object definitions
 	IncludedObjects  []string  `yaml:"included_objects,omitempty"`
 
+	// Composite objects made of several object definitions
+	CompositeObjects []CompositeObject `yaml:"composite_objects,omitempty"`
+
+	// Access of fields by <Definition>.<field>, overriding the access found in the descriptions
+	FieldAccess map[string]FieldAccess `yaml:"field_access,omitempty"`
+
 	// Used to map the group as the resource sees it to the group as the operation sees it
 	GroupMap map[string]string
 
@@ -226,6 +243,8 @@ type Config struct {
 	Operations  Operations
 	SpecTitle   string
 	SpecVersion string
+
+	historyLoaded bool
 }
 
 type Field struct {
@@ -240,6 +259,26 @@ type Field struct {
 	PatchMergeKey string
 
 	Required bool
+	// SchemaRequired is set if the field is in the required list of the schema,
+	// Required also holds for the fields every Kubernetes object is assumed to have
+	SchemaRequired bool
+
+	// Format and pattern of the schema of the field
+	Format  string
+	Pattern string
+
+	// Constraints found in the description and the schema
+	Constraints FieldConstraints
+
+	// Access tells whether users may set the field
+	Access FieldAccess
+
+	// Maturity and deprecation stated in the description
+	Maturity   Maturity
+	Deprecated bool
+
+	// Releases the field appeared, was deprecated or removed in
+	History ReleaseHistory
 }
 
 type Fields []*Field
@@ -376,6 +415,41 @@ type ExampleConfig struct {
 	ResponseNote string `yaml:",omitempty"`
 }
 
+// CompositeObject groups object definitions which are deployed together as one unit
+type CompositeObject struct {
+	// Name is the display name of the composite, e.g. Application
+	Name        string `yaml:",omitempty"`
+	Description string `yaml:",omitempty"`
+	// Members are the objects the composite consists of
+	Members []CompositeMember `yaml:",omitempty"`
+	// Properties are the member fields exposed by the composite
+	Properties []CompositeProperty `yaml:",omitempty"`
+}
+
+type CompositeMember struct {
+	// Name identifies the member within the composite, defaults to the lower case kind
+	Name string `yaml:",omitempty"`
+	Kind string `yaml:",omitempty"`
+}
+
+// CompositeProperty exposes a member field. A property listed for several members
+// sets the field in each of them.
+type CompositeProperty struct {
+	Name string `yaml:",omitempty"`
+	// Member is the name of the member the field belongs to
+	Member string `yaml:",omitempty"`
+	// Path is the dotted path of the field in the member, e.g. spec.replicas
+	Path        string `yaml:",omitempty"`
+	Description string `yaml:",omitempty"`
+}
+
+func (m CompositeMember) GetName() string {
+	if len(m.Name) > 0 {
+		return m.Name
+	}
+	return strings.ToLower(m.Kind)
+}
+
 type SampleConfig struct {
 	Note   string `yaml:",omitempty"`
 	Sample string `yaml:",omitempty"`
@@ -102,6 +102,11 @@ func EscapeAsterisks(des string) string {
 	return s
 }
 
+// UnescapeAsterisks reverts EscapeAsterisks for output that is not markdown
+func UnescapeAsterisks(des string) string {
+	return strings.Replace(des, `\*`, "*", -1)
+}
+
 // IsComplex returns true if the schema is for a complex (non-primitive) definitions
 func IsComplex(schema spec.Schema) bool {
 	_, _, k := GetDefinitionVersionKind(schema)
@@ -0,0 +1,467 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ChangelogDir = flag.String("changelog-dir", "",
+	"Directory the changelog is written to, defaults to build in the work directory.")
+var ChangelogReferenceURL = flag.String("changelog-reference-url",
+	"https://kubernetes.io/docs/reference/generated/kubernetes-api/v%s/",
+	"URL of the reference of a release the changelog links to, %s is replaced by the release.")
+
+// Changelog lists the differences of the API between two releases
+type Changelog struct {
+	From       string
+	To         string
+	FromConfig *api.Config
+	ToConfig   *api.Config
+
+	AddedGroups          []string
+	RemovedGroups        []string
+	AddedGroupVersions   []string
+	RemovedGroupVersions []string
+
+	// Kinds are the definitions with operations
+	AddedKinds         api.SortDefinitionsByName
+	RemovedKinds       api.SortDefinitionsByName
+	AddedDefinitions   api.SortDefinitionsByName
+	RemovedDefinitions api.SortDefinitionsByName
+	ChangedDefinitions []*DefinitionChange
+
+	AddedOperations   []*api.Operation
+	RemovedOperations []*api.Operation
+}
+
+// DefinitionChange lists the changes of the fields of a definition found in both releases
+type DefinitionChange struct {
+	From *api.Definition
+	To   *api.Definition
+
+	AddedFields      api.Fields
+	RemovedFields    api.Fields
+	RetypedFields    []*FieldChange
+	NewlyRequired    []string
+	NoLongerRequired []string
+}
+
+type FieldChange struct {
+	From *api.Field
+	To   *api.Field
+}
+
+func (c *DefinitionChange) IsEmpty() bool {
+	return len(c.AddedFields) == 0 && len(c.RemovedFields) == 0 && len(c.RetypedFields) == 0 &&
+		len(c.NewlyRequired) == 0 && len(c.NoLongerRequired) == 0
+}
+
+// GenerateChangelog writes the changelog between two releases as HTML and Markdown
+func GenerateChangelog(args []string) {
+	if len(args) != 2 {
+		fmt.Printf("Expected the releases to compare, e.g. 1.17 1.18.\n")
+		os.Exit(1)
+	}
+	from := LoadReleaseConfig(args[0])
+	to := LoadReleaseConfig(args[1])
+	changelog := BuildChangelog(args[0], args[1], from, to)
+
+	dir := *ChangelogDir
+	if dir == "" {
+		dir = api.BuildDir
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		panic(err)
+	}
+	name := fmt.Sprintf("changelog-%s-%s", args[0], args[1])
+	writeChangelogFile(filepath.Join(dir, name+".html"), changelog.WriteHTML)
+	writeChangelogFile(filepath.Join(dir, name+".md"), changelog.WriteMarkdown)
+	fmt.Printf("Wrote changelog from %s to %s to %s\n", args[0], args[1], filepath.Join(dir, name))
+}
+
+// LoadReleaseConfig loads the config and specs of a release of the work directory.
+// The global directories are set for the release.
+func LoadReleaseConfig(release string) *api.Config {
+	*api.KubernetesRelease = release
+	return api.NewConfig()
+}
+
+// BuildChangelog compares groups, versions and definitions by their keys and operations by their IDs
+func BuildChangelog(fromRelease, toRelease string, from, to *api.Config) *Changelog {
+	c := &Changelog{From: fromRelease, To: toRelease, FromConfig: from, ToConfig: to}
+
+	c.AddedGroups, c.RemovedGroups = diffKeys(getGroups(from), getGroups(to))
+	c.AddedGroupVersions, c.RemovedGroupVersions = diffKeys(getGroupVersions(from), getGroupVersions(to))
+
+	added, removed := diffKeys(getDefinitionKeys(from), getDefinitionKeys(to))
+	for _, key := range added {
+		d := to.Definitions.All[key]
+		if hasOperations(d) {
+			c.AddedKinds = append(c.AddedKinds, d)
+		} else {
+			c.AddedDefinitions = append(c.AddedDefinitions, d)
+		}
+	}
+	for _, key := range removed {
+		d := from.Definitions.All[key]
+		if hasOperations(d) {
+			c.RemovedKinds = append(c.RemovedKinds, d)
+		} else {
+			c.RemovedDefinitions = append(c.RemovedDefinitions, d)
+		}
+	}
+
+	keys := []string{}
+	for key := range from.Definitions.All {
+		if _, ok := to.Definitions.All[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if change := compareDefinitions(from.Definitions.All[key], to.Definitions.All[key]); !change.IsEmpty() {
+			c.ChangedDefinitions = append(c.ChangedDefinitions, change)
+		}
+	}
+
+	added, removed = diffKeys(getOperationIDs(from), getOperationIDs(to))
+	for _, id := range added {
+		c.AddedOperations = append(c.AddedOperations, to.Operations[id])
+	}
+	for _, id := range removed {
+		c.RemovedOperations = append(c.RemovedOperations, from.Operations[id])
+	}
+	return c
+}
+
+func compareDefinitions(from, to *api.Definition) *DefinitionChange {
+	c := &DefinitionChange{From: from, To: to}
+	fromFields := map[string]*api.Field{}
+	for _, f := range from.Fields {
+		fromFields[f.Name] = f
+	}
+	toFields := map[string]*api.Field{}
+	for _, f := range to.Fields {
+		toFields[f.Name] = f
+		fromField, ok := fromFields[f.Name]
+		switch {
+		case !ok:
+			c.AddedFields = append(c.AddedFields, f)
+		case getFieldTypeKey(fromField) != getFieldTypeKey(f):
+			c.RetypedFields = append(c.RetypedFields, &FieldChange{From: fromField, To: f})
+		}
+	}
+	for _, f := range from.Fields {
+		if _, ok := toFields[f.Name]; !ok {
+			c.RemovedFields = append(c.RemovedFields, f)
+		}
+	}
+
+	fromRequired := map[string]bool{}
+	for _, f := range from.RequiredFields {
+		fromRequired[f] = true
+	}
+	toRequired := map[string]bool{}
+	for _, f := range to.RequiredFields {
+		toRequired[f] = true
+	}
+	c.NewlyRequired, c.NoLongerRequired = diffKeys(fromRequired, toRequired)
+	sort.Sort(c.AddedFields)
+	sort.Sort(c.RemovedFields)
+	return c
+}
+
+// getFieldTypeKey tells apart types of the same name, e.g. of another version
+func getFieldTypeKey(f *api.Field) string {
+	if f.Definition == nil {
+		return f.Type
+	}
+	return f.Type + " " + f.Definition.Key()
+}
+
+func hasOperations(d *api.Definition) bool {
+	for _, c := range d.OperationCategories {
+		if len(c.Operations) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func getGroups(config *api.Config) map[string]bool {
+	groups := map[string]bool{}
+	for g := range config.Definitions.GroupVersions {
+		groups[g] = true
+	}
+	return groups
+}
+
+func getGroupVersions(config *api.Config) map[string]bool {
+	gvs := map[string]bool{}
+	for g, versions := range config.Definitions.GroupVersions {
+		for _, v := range versions {
+			gvs[g+"/"+v.String()] = true
+		}
+	}
+	return gvs
+}
+
+func getDefinitionKeys(config *api.Config) map[string]bool {
+	keys := map[string]bool{}
+	for key := range config.Definitions.All {
+		keys[key] = true
+	}
+	return keys
+}
+
+func getOperationIDs(config *api.Config) map[string]bool {
+	ids := map[string]bool{}
+	for id := range config.Operations {
+		ids[id] = true
+	}
+	return ids
+}
+
+// diffKeys returns the sorted keys only in to and only in from
+func diffKeys(from, to map[string]bool) ([]string, []string) {
+	added, removed := []string{}, []string{}
+	for k := range to {
+		if !from[k] {
+			added = append(added, k)
+		}
+	}
+	for k := range from {
+		if !to[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// getReferenceURL returns the link to an anchor in the reference of a release
+func getReferenceURL(release, anchor string) string {
+	return fmt.Sprintf(*ChangelogReferenceURL, release) + "#" + anchor
+}
+
+func getOperationAnchor(o *api.Operation) string {
+	if o.Definition == nil {
+		return ""
+	}
+	return getLink(o.Type.Name) + "-" + o.Definition.LinkID()
+}
+
+func writeChangelogFile(fn string, write func(io.Writer)) {
+	f, err := os.Create(fn)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+	write(f)
+}
+
+func (c *Changelog) getTitle() string {
+	return fmt.Sprintf("Kubernetes API changes from %s to %s", c.From, c.To)
+}
+
+// WriteHTML writes the changelog as standalone HTML page
+func (c *Changelog) WriteHTML(w io.Writer) {
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<HTML>\n<HEAD>\n<META charset=\"UTF-8\">\n")
+	fmt.Fprintf(w, "<TITLE>%s</TITLE>\n", c.getTitle())
+	fmt.Fprintf(w, "<LINK rel=\"stylesheet\" href=\"/css/bootstrap-4.3.1.min.css\">\n")
+	fmt.Fprintf(w, "<LINK rel=\"stylesheet\" href=\"/css/style_apiref.css\" type=\"text/css\">\n")
+	fmt.Fprintf(w, "</HEAD>\n<BODY>\n<DIV class=\"container-fluid body-content\">\n")
+	fmt.Fprintf(w, "<H1>%s</H1>\n", c.getTitle())
+
+	link := func(release, anchor, text string) string {
+		return fmt.Sprintf("<A href=\"%s\">%s</A>", getReferenceURL(release, anchor), html.EscapeString(text))
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "<H2>%s</H2>\n<UL>\n", title)
+		for _, i := range items {
+			fmt.Fprintf(w, " <LI>%s</LI>\n", i)
+		}
+		fmt.Fprintf(w, "</UL>\n")
+	}
+	definitions := func(release string, defs api.SortDefinitionsByName) []string {
+		items := []string{}
+		for _, d := range defs {
+			items = append(items, link(release, d.LinkID(), getChangelogName(d)))
+		}
+		return items
+	}
+	operations := func(release string, ops []*api.Operation) []string {
+		items := []string{}
+		for _, o := range ops {
+			items = append(items, fmt.Sprintf("%s <CODE>%s</CODE>", link(release, getOperationAnchor(o), o.ID),
+				html.EscapeString(o.GetDisplayHttp())))
+		}
+		return items
+	}
+
+	list("Added groups", codeItems(c.AddedGroups))
+	list("Removed groups", codeItems(c.RemovedGroups))
+	list("Added versions", codeItems(c.AddedGroupVersions))
+	list("Removed versions", codeItems(c.RemovedGroupVersions))
+	list("Added kinds", definitions(c.To, c.AddedKinds))
+	list("Removed kinds", definitions(c.From, c.RemovedKinds))
+	list("Added definitions", definitions(c.To, c.AddedDefinitions))
+	list("Removed definitions", definitions(c.From, c.RemovedDefinitions))
+
+	if len(c.ChangedDefinitions) > 0 {
+		fmt.Fprintf(w, "<H2>Changed definitions</H2>\n")
+	}
+	for _, d := range c.ChangedDefinitions {
+		fmt.Fprintf(w, "<H3>%s</H3>\n<P>%s, %s</P>\n<UL>\n", html.EscapeString(getChangelogName(d.To)),
+			link(c.From, d.From.LinkID(), c.From), link(c.To, d.To.LinkID(), c.To))
+		for _, f := range d.AddedFields {
+			fmt.Fprintf(w, " <LI>Added %s <I>%s</I></LI>\n",
+				link(c.To, d.To.LinkID(), f.Name), html.EscapeString(f.Type))
+		}
+		for _, f := range d.RemovedFields {
+			fmt.Fprintf(w, " <LI>Removed %s <I>%s</I></LI>\n",
+				link(c.From, d.From.LinkID(), f.Name), html.EscapeString(f.Type))
+		}
+		for _, f := range d.RetypedFields {
+			fmt.Fprintf(w, " <LI>Changed type of %s from <I>%s</I> to <I>%s</I></LI>\n",
+				link(c.To, d.To.LinkID(), f.To.Name), html.EscapeString(getChangelogType(f.From)),
+				html.EscapeString(getChangelogType(f.To)))
+		}
+		for _, f := range d.NewlyRequired {
+			fmt.Fprintf(w, " <LI><CODE>%s</CODE> is required</LI>\n", f)
+		}
+		for _, f := range d.NoLongerRequired {
+			fmt.Fprintf(w, " <LI><CODE>%s</CODE> is no longer required</LI>\n", f)
+		}
+		fmt.Fprintf(w, "</UL>\n")
+	}
+
+	list("Added operations", operations(c.To, c.AddedOperations))
+	list("Removed operations", operations(c.From, c.RemovedOperations))
+	fmt.Fprintf(w, "</DIV>\n</BODY>\n</HTML>\n")
+}
+
+// WriteMarkdown writes the changelog as Markdown page with front matter
+func (c *Changelog) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "---\ntitle: %q\n---\n\n# %s\n\n", c.getTitle(), c.getTitle())
+
+	link := func(release, anchor, text string) string {
+		return fmt.Sprintf("[%s](%s)", text, getReferenceURL(release, anchor))
+	}
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "## %s\n\n", title)
+		for _, i := range items {
+			fmt.Fprintf(w, "- %s\n", i)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	code := func(items []string) []string {
+		quoted := []string{}
+		for _, i := range items {
+			quoted = append(quoted, "`"+i+"`")
+		}
+		return quoted
+	}
+	definitions := func(release string, defs api.SortDefinitionsByName) []string {
+		items := []string{}
+		for _, d := range defs {
+			items = append(items, link(release, d.LinkID(), getChangelogName(d)))
+		}
+		return items
+	}
+	operations := func(release string, ops []*api.Operation) []string {
+		items := []string{}
+		for _, o := range ops {
+			items = append(items, fmt.Sprintf("%s `%s`", link(release, getOperationAnchor(o), o.ID), o.GetDisplayHttp()))
+		}
+		return items
+	}
+
+	list("Added groups", code(c.AddedGroups))
+	list("Removed groups", code(c.RemovedGroups))
+	list("Added versions", code(c.AddedGroupVersions))
+	list("Removed versions", code(c.RemovedGroupVersions))
+	list("Added kinds", definitions(c.To, c.AddedKinds))
+	list("Removed kinds", definitions(c.From, c.RemovedKinds))
+	list("Added definitions", definitions(c.To, c.AddedDefinitions))
+	list("Removed definitions", definitions(c.From, c.RemovedDefinitions))
+
+	if len(c.ChangedDefinitions) > 0 {
+		fmt.Fprintf(w, "## Changed definitions\n\n")
+	}
+	for _, d := range c.ChangedDefinitions {
+		fmt.Fprintf(w, "### %s\n\n%s, %s\n\n", getChangelogName(d.To),
+			link(c.From, d.From.LinkID(), c.From), link(c.To, d.To.LinkID(), c.To))
+		for _, f := range d.AddedFields {
+			fmt.Fprintf(w, "- Added %s *%s*\n", link(c.To, d.To.LinkID(), "`"+f.Name+"`"), f.Type)
+		}
+		for _, f := range d.RemovedFields {
+			fmt.Fprintf(w, "- Removed %s *%s*\n", link(c.From, d.From.LinkID(), "`"+f.Name+"`"), f.Type)
+		}
+		for _, f := range d.RetypedFields {
+			fmt.Fprintf(w, "- Changed type of %s from *%s* to *%s*\n",
+				link(c.To, d.To.LinkID(), "`"+f.To.Name+"`"), getChangelogType(f.From), getChangelogType(f.To))
+		}
+		for _, f := range d.NewlyRequired {
+			fmt.Fprintf(w, "- `%s` is required\n", f)
+		}
+		for _, f := range d.NoLongerRequired {
+			fmt.Fprintf(w, "- `%s` is no longer required\n", f)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	list("Added operations", operations(c.To, c.AddedOperations))
+	list("Removed operations", operations(c.From, c.RemovedOperations))
+}
+
+func codeItems(items []string) []string {
+	quoted := []string{}
+	for _, i := range items {
+		quoted = append(quoted, "<CODE>"+html.EscapeString(i)+"</CODE>")
+	}
+	return quoted
+}
+
+func getChangelogName(d *api.Definition) string {
+	return fmt.Sprintf("%s %s %s", d.Name, d.Version, d.GroupDisplayName())
+}
+
+// getChangelogType adds the version to types of definitions, e.g. HorizontalPodAutoscalerSpec v2beta2
+func getChangelogType(f *api.Field) string {
+	if f.Definition == nil {
+		return f.Type
+	}
+	return strings.Replace(f.Type, f.Definition.Name, f.Definition.Name+" "+f.Definition.Version.String(), 1)
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var GraphFormat = flag.String("graph-format", GraphFormatDot, "Format of the definition graph: dot, mermaid or graphml.")
+var GraphFile = flag.String("graph-file", "",
+	"File the definition graph is written to, defaults to build/definitions.<format> in the work directory.")
+var GraphGroups = flag.String("graph-groups", "", "Comma separated groups the definition graph is limited to, e.g. apps,core.")
+var GraphDepth = flag.Int("graph-depth", 0, "Number of references followed from the given kinds, 0 follows all.")
+var GraphVersions = flag.Bool("graph-versions", false, "If true, link the other versions of the definitions in the graph.")
+
+const GraphFormatDot = "dot"
+const GraphFormatMermaid = "mermaid"
+const GraphFormatGraphML = "graphml"
+
+// Kinds of edges between definitions
+const GraphEdgeField = "field"
+const GraphEdgeInline = "inline"
+const GraphEdgeVersion = "version"
+
+var mermaidIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+type DefinitionGraph struct {
+	Nodes api.SortDefinitionsByName
+	Edges []GraphEdge
+}
+
+// GraphEdge links a definition to one it refers to, labelled with the fields referring to it
+type GraphEdge struct {
+	From  *api.Definition
+	To    *api.Definition
+	Type  string
+	Label string
+}
+
+// GenerateGraph writes the graph of the definitions reachable from the given kinds, or of all
+// definitions if none are given.
+func GenerateGraph(kinds []string) {
+	config := api.NewConfig()
+	roots := []*api.Definition{}
+	for _, kind := range kinds {
+		defs, ok := config.Definitions.ByKind[kind]
+		if !ok {
+			fmt.Printf("Unknown kind %s.\n", kind)
+			os.Exit(1)
+		}
+		roots = append(roots, defs[0])
+	}
+	groups := map[string]bool{}
+	for _, g := range strings.Split(*GraphGroups, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			groups[g] = true
+		}
+	}
+
+	graph := BuildDefinitionGraph(config, roots, groups, *GraphDepth)
+	var out string
+	switch *GraphFormat {
+	case GraphFormatDot:
+		out = graph.Dot()
+	case GraphFormatMermaid:
+		out = graph.Mermaid()
+	case GraphFormatGraphML:
+		out = graph.GraphML()
+	default:
+		fmt.Printf("Unknown --graph-format %s, expected %s, %s or %s\n", *GraphFormat,
+			GraphFormatDot, GraphFormatMermaid, GraphFormatGraphML)
+		os.Exit(1)
+	}
+
+	fn := *GraphFile
+	if fn == "" {
+		fn = filepath.Join(api.BuildDir, "definitions."+*GraphFormat)
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), os.ModePerm); err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(fn, []byte(out), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Wrote %d definitions and %d references to %s\n", len(graph.Nodes), len(graph.Edges), fn)
+}
+
+// BuildDefinitionGraph follows field and inline references from roots, up to depth references
+// unless depth is 0, keeping only definitions of the given groups if any are given.
+// Without roots all definitions are in the graph.
+func BuildDefinitionGraph(config *api.Config, roots []*api.Definition, groups map[string]bool, depth int) *DefinitionGraph {
+	included := func(d *api.Definition) bool {
+		return len(groups) == 0 || groups[d.Group.String()]
+	}
+
+	nodes := map[*api.Definition]bool{}
+	if len(roots) == 0 {
+		for _, d := range config.Definitions.All {
+			if included(d) {
+				nodes[d] = true
+			}
+		}
+	}
+	level := []*api.Definition{}
+	for _, d := range roots {
+		if included(d) && !nodes[d] {
+			nodes[d] = true
+			level = append(level, d)
+		}
+	}
+	for i := 0; len(level) > 0 && (depth == 0 || i < depth); i++ {
+		next := []*api.Definition{}
+		for _, d := range level {
+			for _, r := range getGraphReferences(d) {
+				if included(r) && !nodes[r] {
+					nodes[r] = true
+					next = append(next, r)
+				}
+			}
+		}
+		level = next
+	}
+
+	graph := &DefinitionGraph{}
+	for d := range nodes {
+		graph.Nodes = append(graph.Nodes, d)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Key() < graph.Nodes[j].Key() })
+
+	for _, d := range graph.Nodes {
+		labels := map[*api.Definition][]string{}
+		targets := []*api.Definition{}
+		for _, field := range d.Fields {
+			if !field.HasComplexType() || !nodes[field.Definition] {
+				continue
+			}
+			if _, ok := labels[field.Definition]; !ok {
+				targets = append(targets, field.Definition)
+			}
+			name := field.Name
+			if IsArray(field.Type) {
+				name += "[]"
+			}
+			labels[field.Definition] = append(labels[field.Definition], name)
+		}
+		for _, t := range targets {
+			sort.Strings(labels[t])
+			graph.Edges = append(graph.Edges, GraphEdge{From: d, To: t, Type: GraphEdgeField, Label: strings.Join(labels[t], ", ")})
+		}
+		for _, i := range d.Inline {
+			if _, ok := labels[i]; !ok && nodes[i] {
+				graph.Edges = append(graph.Edges, GraphEdge{From: d, To: i, Type: GraphEdgeInline, Label: GraphEdgeInline})
+			}
+		}
+		if *GraphVersions {
+			for _, o := range d.OtherVersions {
+				// link each pair of versions once, from the newer version
+				if nodes[o] && o.Version.LessThan(d.Version) {
+					graph.Edges = append(graph.Edges, GraphEdge{From: d, To: o, Type: GraphEdgeVersion, Label: o.Version.String()})
+				}
+			}
+		}
+	}
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From.Key() < b.From.Key()
+		}
+		return a.To.Key() < b.To.Key()
+	})
+	return graph
+}
+
+func getGraphReferences(d *api.Definition) []*api.Definition {
+	refs := append([]*api.Definition{}, d.Inline...)
+	for _, field := range d.Fields {
+		if field.HasComplexType() {
+			refs = append(refs, field.Definition)
+		}
+	}
+	return refs
+}
+
+func getGraphLabel(d *api.Definition) string {
+	return d.Name + " (" + d.GroupVersion() + ")"
+}
+
+// Dot returns the graph in the Graphviz language
+func (g *DefinitionGraph) Dot() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "digraph definitions {\n  rankdir=LR;\n  node [shape=box];\n")
+	for _, d := range g.Nodes {
+		fmt.Fprintf(b, "  %q [label=%q];\n", d.Key(), getGraphLabel(d))
+	}
+	for _, e := range g.Edges {
+		style := ""
+		switch e.Type {
+		case GraphEdgeInline:
+			style = ", style=dashed"
+		case GraphEdgeVersion:
+			style = ", style=dotted"
+		}
+		fmt.Fprintf(b, "  %q -> %q [label=%q%s];\n", e.From.Key(), e.To.Key(), e.Label, style)
+	}
+	fmt.Fprintf(b, "}\n")
+	return b.String()
+}
+
+// Mermaid returns the graph as a Mermaid flowchart
+func (g *DefinitionGraph) Mermaid() string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "graph LR\n")
+	for _, d := range g.Nodes {
+		fmt.Fprintf(b, "  %s[\"%s<br/>%s\"]\n", getMermaidID(d), d.Name, d.GroupVersion())
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Type != GraphEdgeField {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(b, "  %s %s|\"%s\"| %s\n", getMermaidID(e.From), arrow, e.Label, getMermaidID(e.To))
+	}
+	return b.String()
+}
+
+func getMermaidID(d *api.Definition) string {
+	return mermaidIdentifier.ReplaceAllString(d.Key(), "_")
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GraphML returns the graph as GraphML, with the kind, group and version of nodes
+// and the type and fields of edges as data
+func (g *DefinitionGraph) GraphML() string {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "kind", For: "node", AttrName: "kind", AttrType: "string"},
+			{ID: "group", For: "node", AttrName: "group", AttrType: "string"},
+			{ID: "version", For: "node", AttrName: "version", AttrType: "string"},
+			{ID: "type", For: "edge", AttrName: "type", AttrType: "string"},
+			{ID: "label", For: "edge", AttrName: "label", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "definitions", EdgeDefault: "directed"},
+	}
+	for _, d := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: d.Key(),
+			Data: []graphMLData{
+				{Key: "kind", Value: d.Name},
+				{Key: "group", Value: d.Group.String()},
+				{Key: "version", Value: d.Version.String()},
+			},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.From.Key(),
+			Target: e.To.Key(),
+			Data: []graphMLData{
+				{Key: "type", Value: e.Type},
+				{Key: "label", Value: e.Label},
+			},
+		})
+	}
+	b, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return xml.Header + string(b) + "\n"
+}
@@ -36,6 +36,8 @@ type TOCItem struct {
 	Link        string
 	File        string
 	SubSections []*TOCItem
+	// Badges are shown after the title in the navigation
+	Badges string
 }
 
 type TOC struct {
@@ -48,6 +50,8 @@ type HTMLWriter struct {
 	Config         *api.Config
 	TOC            TOC
 	CurrentSection *TOCItem
+
+	SearchDocuments []*SearchDocument
 }
 
 func NewHTMLWriter(config *api.Config, copyright, title string) DocWriter {
@@ -152,6 +156,28 @@ func (h *HTMLWriter) writeOtherVersions(w io.Writer, d *api.Definition) {
 	fmt.Fprintf(w, "</DIV>\n")
 }
 
+func (h *HTMLWriter) writeResourceLinks(w io.Writer, r *api.Resource) {
+	concept, hasConcept := GetConceptLink(r)
+	tasks := GetRelatedTaskLinks(r)
+	if !hasConcept && len(tasks) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "<DIV class=\"alert alert-secondary col-md-8\">")
+	if hasConcept {
+		fmt.Fprintf(w, "<P><I class=\"fa fa-book\"></I> <B>Concepts:</B> <A href=\"%s\">%s</A></P>\n",
+			concept.URL, html.EscapeString(concept.Title))
+	}
+	if len(tasks) > 0 {
+		fmt.Fprintf(w, "<P><I class=\"fa fa-tasks\"></I> <B>Related tasks:</B></P>\n <UL>\n")
+		for _, t := range tasks {
+			fmt.Fprintf(w, "  <LI><A href=\"%s\">%s</A></LI>\n", t.URL, html.EscapeString(t.Title))
+		}
+		fmt.Fprintf(w, " </UL>\n")
+	}
+	fmt.Fprintf(w, "</DIV>\n")
+}
+
 func (h *HTMLWriter) writeAppearsIn(w io.Writer, d *api.Definition) {
 	if d.AppearsIn.Len() != 0 {
 		fmt.Fprintf(w, "<DIV class=\"alert alert-info col-md-8\"><I class=\"fa fa-info-circle\"></I> Appears In:\n <UL>\n")
@@ -165,22 +191,45 @@ func (h *HTMLWriter) writeAppearsIn(w io.Writer, d *api.Definition) {
 func (h *HTMLWriter) writeFields(w io.Writer, d *api.Definition) {
 	fmt.Fprintf(w, "<TABLE>\n<THEAD><TR><TH>Field</TH><TH>Description</TH></TR></THEAD>\n<TBODY>\n")
 
-	for _, field := range d.Fields {
-		fmt.Fprintf(w, "<TR><TD><CODE>%s</CODE>", field.Name)
+	for _, field := range SortFields(d.Fields) {
+		fmt.Fprintf(w, "<TR id=\"%s\"><TD><CODE>%s</CODE>", getFieldAnchor(d, field), field.Name)
+		h.writeFieldBadges(w, field)
 		if field.Link() != "" {
 			fmt.Fprintf(w, "<BR /><I>%s</I>", field.FullLink())
 		}
+		h.writeFieldFormat(w, field)
 		if field.PatchStrategy != "" {
 			fmt.Fprintf(w, "<BR /><B>patch strategy</B>: <I>%s</I>", field.PatchStrategy)
 		}
 		if field.PatchMergeKey != "" {
 			fmt.Fprintf(w, "<BR /><B>patch merge key</B>: <I>%s</I>", field.PatchMergeKey)
 		}
+		h.writeConstraints(w, field.Constraints)
 		fmt.Fprintf(w, "</TD><TD>%s</TD></TR>\n", field.DescriptionWithEntities)
 	}
 	fmt.Fprintf(w, "</TBODY>\n</TABLE>\n")
 }
 
+func (h *HTMLWriter) writeConstraints(w io.Writer, c api.FieldConstraints) {
+	if c.IsEmpty() {
+		return
+	}
+	if c.Default != nil {
+		fmt.Fprintf(w, "<BR /><B>default</B>: <I>%s</I> <SMALL>(%s confidence)</SMALL>",
+			html.EscapeString(fmt.Sprintf("%#v", c.Default)), c.DefaultConfidence)
+	}
+	if len(c.AllowedValues) > 0 {
+		fmt.Fprintf(w, "<BR /><B>allowed values</B>: <I>%s</I> <SMALL>(%s confidence)</SMALL>",
+			html.EscapeString(strings.Join(c.AllowedValues, ", ")), c.AllowedValuesConfidence)
+	}
+	if c.Minimum != nil {
+		fmt.Fprintf(w, "<BR /><B>minimum</B>: <I>%d</I> <SMALL>(%s confidence)</SMALL>", *c.Minimum, c.BoundsConfidence)
+	}
+	if c.Maximum != nil {
+		fmt.Fprintf(w, "<BR /><B>maximum</B>: <I>%d</I> <SMALL>(%s confidence)</SMALL>", *c.Maximum, c.BoundsConfidence)
+	}
+}
+
 func (h *HTMLWriter) WriteDefinitionsOverview() {
 	writeStaticFile("Definitions", "_definitions.html", h.DefaultStaticContent("Definitions"))
 	item := TOCItem{
@@ -214,12 +263,14 @@ func (h *HTMLWriter) WriteDefinition(d *api.Definition) {
 	h.writeOtherVersions(f, d)
 	h.writeAppearsIn(f, d)
 	h.writeFields(f, d)
+	h.addDefinitionDocument(d, linkID)
 
 	item := TOCItem{
-		Level: 2,
-		Title: nvg,
-		Link:  linkID,
-		File:  fn,
+		Level:  2,
+		Title:  nvg,
+		Link:   linkID,
+		File:   fn,
+		Badges: getHistoryBadges(d.History),
 	}
 	h.CurrentSection.SubSections = append(h.CurrentSection.SubSections, &item)
 }
@@ -379,9 +430,11 @@ func (h *HTMLWriter) WriteResource(r *api.Resource) {
 		fmt.Fprintf(w, "<DIV class=\"alert alert-info col-md-8\"><I class=\"fa fa-bullhorn\"></I> %s</DIV>\n", r.DescriptionNote)
 	}
 
+	h.writeResourceLinks(w, r)
 	h.writeOtherVersions(w, r.Definition)
 	h.writeAppearsIn(w, r.Definition)
 	h.writeFields(w, r.Definition)
+	h.writeFieldTree(w, r)
 
 	// Inline
 	if r.Definition.Inline.Len() > 0 {
@@ -389,14 +442,17 @@ func (h *HTMLWriter) WriteResource(r *api.Resource) {
 			fmt.Fprintf(w, "<H3 id=\"%s\">%s %s %s</H3>\n", d.LinkID(), d.Name, d.Version, d.Group)
 			h.writeAppearsIn(w, d)
 			h.writeFields(w, d)
+			h.addDefinitionDocument(d, d.LinkID())
 		}
 	}
+	h.addResourceDocuments(r, linkID)
 
 	item := TOCItem{
-		Level: 1,
-		Title: dvg,
-		Link:  linkID,
-		File:  fn,
+		Level:  1,
+		Title:  dvg,
+		Link:   linkID,
+		File:   fn,
+		Badges: getHistoryBadges(r.Definition.History),
 	}
 	h.TOC.Sections = append(h.TOC.Sections, &item)
 	h.CurrentSection = &item
@@ -471,8 +527,8 @@ func (h *HTMLWriter) generateNavContent() string {
 		if strings.Contains(sec.Link, "strong") {
 			nav += fmt.Sprintf(" <LI class=\"nav-level-1 strong-nav\"><A href=\"#%s\" class=\"nav-item\"><STRONG>%s</STRONG></A></LI>\n", sec.Link, sec.Title)
 		} else {
-			nav += fmt.Sprintf(" <LI class=\"nav-level-1\"><A href=\"#%s\" class=\"nav-item\">%s</A></LI>\n",
-				sec.Link, sec.Title)
+			nav += fmt.Sprintf(" <LI class=\"nav-level-1\"><A href=\"#%s\" class=\"nav-item\">%s</A>%s</LI>\n",
+				sec.Link, sec.Title, sec.Badges)
 		}
 
 		// close H1 items which have no subsections or strong navs
@@ -493,8 +549,8 @@ func (h *HTMLWriter) generateNavContent() string {
 				nav += fmt.Sprintf("   <LI class=\"nav-level-%d strong-nav\"><A href=\"#%s\" class=\"nav-item\"><STRONG>%s</STRONG></A></LI>\n",
 					sub.Level, sub.Link, sub.Title)
 			} else {
-				nav += fmt.Sprintf("   <LI class=\"nav-level-%d\"><A href=\"#%s\" class=\"nav-item\">%s</A></LI>\n",
-					sub.Level, sub.Link, sub.Title)
+				nav += fmt.Sprintf("   <LI class=\"nav-level-%d\"><A href=\"#%s\" class=\"nav-item\">%s</A>%s</LI>\n",
+					sub.Level, sub.Link, sub.Title, sub.Badges)
 			}
 			// close this H1/H2 if possible
 			if len(sub.SubSections) == 0 {
@@ -589,31 +645,10 @@ func (h *HTMLWriter) generateHTML(navContent string) {
 		os.Exit(1)
 	}
 
-	/* Make sure the following stylesheets exist in kubernetes/website repo:
-	   kubernetes/website/static/css/bootstrap-4.3.1.min.css
-	   kubernetes/website/static/css/fontawesome-4.7.0.min.css
-	   kubernetes/website/static/css/style_apiref.css
-	*/
-	fmt.Fprintf(html, "<!DOCTYPE html>\n<HTML>\n<HEAD>\n<META charset=\"UTF-8\">\n")
-	fmt.Fprintf(html, "<TITLE>%s</TITLE>\n", h.TOC.Title)
-	fmt.Fprintf(html, "<LINK rel=\"shortcut icon\" href=\"favicon.ico\" type=\"image/vnd.microsoft.icon\">\n")
-	fmt.Fprintf(html, "<LINK rel=\"stylesheet\" href=\"/css/bootstrap-4.3.1.min.css\">\n")
-	fmt.Fprintf(html, "<LINK rel=\"stylesheet\" href=\"/css/fontawesome-4.7.0.min.css\" type=\"text/css\">\n")
-	fmt.Fprintf(html, "<LINK rel=\"stylesheet\" href=\"/css/style_apiref.css\" type=\"text/css\">\n")
-	fmt.Fprintf(html, "</HEAD>\n<BODY>\n")
-	fmt.Fprintf(html, "<DIV id=\"wrapper\" class=\"container-fluid\">\n")
-	fmt.Fprintf(html, "<DIV class=\"row\">\n")
-	fmt.Fprintf(html, "<DIV id=\"sidebar-wrapper\" class=\"col-xs-4 col-sm-3 col-md-2 side-nav side-bar-nav\">\n")
+	h.writeHead(html, h.TOC.Title)
 
 	// html buffer
-	buf := "<DIV class=\"row\">\n  <DIV class=\"col-md-6 copyright\">\n " + h.TOC.Copyright + "\n  </DIV>\n"
-	buf += "  <DIV class=\"col-md-6 text-right\">\n"
-	buf += fmt.Sprintf("    <DIV>Generated at: %s</DIV>\n", time.Now().Format("2006-01-02 15:04:05 (MST)"))
-	pos := strings.LastIndex(h.Config.SpecVersion, ".")
-	release := fmt.Sprintf("release-%s", h.Config.SpecVersion[1:pos])
-	spec_link := "https://github.com/kubernetes/kubernetes/blob/" + release + "/api/openapi-spec/swagger.json"
-	buf += fmt.Sprintf("    <DIV>API Version: <a href=\"%s\">%s</a></DIV>\n", spec_link, h.Config.SpecVersion)
-	buf += "  </DIV>\n</DIV>"
+	buf := h.getCopyright()
 	const OK = "\033[32mOK\033[0m"
 	const NOT_FOUND = "\033[31mNot found\033[0m"
 	for _, sec := range h.TOC.Sections {
@@ -662,7 +697,7 @@ func (h *HTMLWriter) generateHTML(navContent string) {
 
 		navData.js is dynamically generated - see generateNavJS()
 	*/
-	fmt.Fprintf(html, "%s</DIV>\n", navContent)
+	fmt.Fprintf(html, "%s%s</DIV>\n", getSearchWidget(), navContent)
 	fmt.Fprintf(html, "<DIV id=\"page-content-wrapper\" class=\"col-xs-8 offset-xs-4 col-sm-9 offset-sm-3 col-md-10 offset-md-2 body-content\">\n")
 	fmt.Fprintf(html, "%s", string(buf))
 	fmt.Fprintf(html, "\n</DIV>\n</DIV>\n</DIV>\n")
@@ -671,14 +706,50 @@ func (h *HTMLWriter) generateHTML(navContent string) {
 	fmt.Fprintf(html, "<SCRIPT src=\"/js/bootstrap-4.3.1.min.js\"></SCRIPT>\n")
 	fmt.Fprintf(html, "<SCRIPT src=\"js/navData.js\"></SCRIPT>\n")
 	fmt.Fprintf(html, "<SCRIPT src=\"/js/scroll-apiref.js\"></SCRIPT>\n")
+	fmt.Fprintf(html, "%s", getSearchScripts())
 	fmt.Fprintf(html, "</BODY>\n</HTML>\n")
 }
 
+// writeHead writes the page up to the start of the side navigation
+func (h *HTMLWriter) writeHead(w io.Writer, title string) {
+	/* Make sure the following stylesheets exist in kubernetes/website repo:
+	   kubernetes/website/static/css/bootstrap-4.3.1.min.css
+	   kubernetes/website/static/css/fontawesome-4.7.0.min.css
+	   kubernetes/website/static/css/style_apiref.css
+	*/
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<HTML>\n<HEAD>\n<META charset=\"UTF-8\">\n")
+	fmt.Fprintf(w, "<TITLE>%s</TITLE>\n", title)
+	fmt.Fprintf(w, "<LINK rel=\"shortcut icon\" href=\"favicon.ico\" type=\"image/vnd.microsoft.icon\">\n")
+	fmt.Fprintf(w, "<LINK rel=\"stylesheet\" href=\"/css/bootstrap-4.3.1.min.css\">\n")
+	fmt.Fprintf(w, "<LINK rel=\"stylesheet\" href=\"/css/fontawesome-4.7.0.min.css\" type=\"text/css\">\n")
+	fmt.Fprintf(w, "<LINK rel=\"stylesheet\" href=\"/css/style_apiref.css\" type=\"text/css\">\n")
+	fmt.Fprintf(w, "</HEAD>\n<BODY>\n")
+	fmt.Fprintf(w, "<DIV id=\"wrapper\" class=\"container-fluid\">\n")
+	fmt.Fprintf(w, "<DIV class=\"row\">\n")
+	fmt.Fprintf(w, "<DIV id=\"sidebar-wrapper\" class=\"col-xs-4 col-sm-3 col-md-2 side-nav side-bar-nav\">\n")
+}
+
+// getCopyright returns the copyright and version row at the top of the content
+func (h *HTMLWriter) getCopyright() string {
+	buf := "<DIV class=\"row\">\n  <DIV class=\"col-md-6 copyright\">\n " + h.TOC.Copyright + "\n  </DIV>\n"
+	buf += "  <DIV class=\"col-md-6 text-right\">\n"
+	buf += fmt.Sprintf("    <DIV>Generated at: %s</DIV>\n", time.Now().Format("2006-01-02 15:04:05 (MST)"))
+	pos := strings.LastIndex(h.Config.SpecVersion, ".")
+	release := fmt.Sprintf("release-%s", h.Config.SpecVersion[1:pos])
+	spec_link := "https://github.com/kubernetes/kubernetes/blob/" + release + "/api/openapi-spec/swagger.json"
+	buf += fmt.Sprintf("    <DIV>API Version: <a href=\"%s\">%s</a></DIV>\n", spec_link, h.Config.SpecVersion)
+	buf += "  </DIV>\n</DIV>"
+	return buf
+}
+
 func (h *HTMLWriter) Finalize() {
 	// generate NavData
 	os.MkdirAll(api.BuildDir, os.ModePerm)
 
 	h.generateNavJS()
+	h.writeSearchIndex(api.BuildDir, api.BuildDir, func(anchor string) string {
+		return "#" + anchor
+	})
 	navContent := h.generateNavContent()
 	h.generateHTML(navContent)
 }
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var FieldSort = flag.String("field-sort", FieldSortAlphabetical,
+	"Order of the fields in the HTML reference: alphabetical or required-first.")
+
+const FieldSortAlphabetical = "alphabetical"
+const FieldSortRequiredFirst = "required-first"
+
+// SortFields returns the fields in the order of --field-sort
+func SortFields(fields api.Fields) api.Fields {
+	sorted := append(api.Fields{}, fields...)
+	if *FieldSort != FieldSortRequiredFirst {
+		sort.Sort(sorted)
+		return sorted
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].SchemaRequired != sorted[j].SchemaRequired {
+			return sorted[i].SchemaRequired
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// writeFieldBadges writes whether the field is required or not settable and its release history
+func (h *HTMLWriter) writeFieldBadges(w io.Writer, field *api.Field) {
+	if field.SchemaRequired {
+		fmt.Fprintf(w, " <SPAN class=\"badge badge-danger\">required</SPAN>")
+	}
+	if !field.Access.IsSettable() {
+		fmt.Fprintf(w, " <SPAN class=\"badge badge-secondary\">%s</SPAN>", field.Access)
+	}
+	fmt.Fprintf(w, "%s", getHistoryBadges(field.History))
+}
+
+// getHistoryBadges returns the releases a definition or field appeared, was deprecated or removed in
+func getHistoryBadges(history api.ReleaseHistory) string {
+	badges := ""
+	if history.Since != "" {
+		badges += fmt.Sprintf(" <SPAN class=\"badge badge-info\">since %s</SPAN>", history.Since)
+	}
+	if history.DeprecatedIn != "" {
+		badges += fmt.Sprintf(" <SPAN class=\"badge badge-warning\">deprecated in %s</SPAN>", history.DeprecatedIn)
+	}
+	if history.RemovedIn != "" {
+		badges += fmt.Sprintf(" <SPAN class=\"badge badge-dark\">removed in %s</SPAN>", history.RemovedIn)
+	}
+	return badges
+}
+
+// writeFieldFormat writes the format and pattern of the schema of the field
+func (h *HTMLWriter) writeFieldFormat(w io.Writer, field *api.Field) {
+	if field.Format != "" {
+		fmt.Fprintf(w, "<BR /><B>format</B>: <I>%s</I>", html.EscapeString(field.Format))
+	}
+	if field.Pattern != "" {
+		fmt.Fprintf(w, "<BR /><B>pattern</B>: <CODE>%s</CODE>", html.EscapeString(field.Pattern))
+	}
+}
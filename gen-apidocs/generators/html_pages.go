@@ -0,0 +1,237 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var HTMLPagesDir = flag.String("html-pages-dir", "",
+	"Directory the multi-page HTML reference is written to, defaults to build/html in the work directory.")
+
+// Name of the page of the first section, the overview
+const HTMLIndexPage = "index"
+
+var (
+	htmlIDPattern     = regexp.MustCompile(`\sid="([^"]+)"`)
+	htmlAnchorPattern = regexp.MustCompile(`href="#([^"]+)"`)
+)
+
+// HTMLPagesWriter writes the sections of the HTML reference as pages with a shared navigation,
+// a page per resource and definition and an index page per category.
+type HTMLPagesWriter struct {
+	*HTMLWriter
+	Dir string
+}
+
+func NewHTMLPagesWriter(config *api.Config, copyright, title string) DocWriter {
+	dir := *HTMLPagesDir
+	if dir == "" {
+		dir = filepath.Join(api.BuildDir, "html")
+	}
+	return &HTMLPagesWriter{
+		HTMLWriter: NewHTMLWriter(config, copyright, title).(*HTMLWriter),
+		Dir:        dir,
+	}
+}
+
+type htmlPage struct {
+	Name     string
+	Title    string
+	Section  *TOCItem
+	Children []*htmlPage
+	Content  string
+}
+
+func (h *HTMLPagesWriter) Finalize() {
+	if err := os.MkdirAll(filepath.Join(h.Dir, "js"), os.ModePerm); err != nil {
+		panic(err)
+	}
+
+	pages := h.getPages()
+	anchors := map[string]string{}
+	for _, p := range pages {
+		for _, m := range htmlIDPattern.FindAllStringSubmatch(p.Content, -1) {
+			if _, ok := anchors[m[1]]; !ok {
+				anchors[m[1]] = p.Name
+			}
+		}
+	}
+
+	for _, p := range pages {
+		h.writePage(p, pages, anchors)
+	}
+	h.writeAnchors(anchors)
+	h.writeSearchIndex(h.Dir, filepath.Join(h.Dir, "js"), func(anchor string) string {
+		return getPageURL(anchor, anchors)
+	})
+	fmt.Printf("Wrote %d HTML pages to %s\n", len(pages), h.Dir)
+}
+
+// getPages returns a page per top level section, and per sub section with a file such as
+// the definitions. Resources are the children of the category before them.
+func (h *HTMLPagesWriter) getPages() []*htmlPage {
+	pages := []*htmlPage{}
+	var index *htmlPage
+	for _, sec := range h.TOC.Sections {
+		p := &htmlPage{Name: getPageName(sec.Link), Title: sec.Title, Section: sec}
+		if len(pages) == 0 {
+			p.Name = HTMLIndexPage
+		}
+		p.Content = readInclude(sec.File)
+		pages = append(pages, p)
+
+		if !strings.Contains(sec.Link, "strong") {
+			if index != nil {
+				index.Children = append(index.Children, p)
+			}
+			continue
+		}
+		index = p
+		for _, sub := range sec.SubSections {
+			if len(sub.File) == 0 {
+				continue
+			}
+			sp := &htmlPage{Name: getPageName(sub.Link), Title: sub.Title, Section: sub, Content: readInclude(sub.File)}
+			pages = append(pages, sp)
+			p.Children = append(p.Children, sp)
+		}
+	}
+	return pages
+}
+
+func (h *HTMLPagesWriter) writePage(p *htmlPage, pages []*htmlPage, anchors map[string]string) {
+	f, err := os.Create(filepath.Join(h.Dir, p.Name+".html"))
+	defer f.Close()
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("%v", err))
+		os.Exit(1)
+	}
+
+	title := h.TOC.Title
+	if p.Name != HTMLIndexPage {
+		title = p.Title + " - " + title
+	}
+	h.writeHead(f, title)
+	fmt.Fprintf(f, "%s%s</DIV>\n", getSearchWidget(), h.generatePageNav(p, pages))
+	fmt.Fprintf(f, "<DIV id=\"page-content-wrapper\" class=\"col-xs-8 offset-xs-4 col-sm-9 offset-sm-3 col-md-10 offset-md-2 body-content\">\n")
+	fmt.Fprintf(f, "%s", h.getCopyright())
+	fmt.Fprintf(f, "%s", replacePageAnchors(p.Content, p.Name, anchors))
+	if len(p.Children) > 0 {
+		fmt.Fprintf(f, "<UL>\n")
+		for _, c := range p.Children {
+			fmt.Fprintf(f, " <LI><A href=\"%s.html\">%s</A>%s</LI>\n", c.Name, c.Title, c.Section.Badges)
+		}
+		fmt.Fprintf(f, "</UL>\n")
+	}
+	fmt.Fprintf(f, "\n</DIV>\n</DIV>\n</DIV>\n")
+	fmt.Fprintf(f, "<SCRIPT src=\"/js/jquery-3.3.1.min.js\"></SCRIPT>\n")
+	fmt.Fprintf(f, "<SCRIPT src=\"/js/bootstrap-4.3.1.min.js\"></SCRIPT>\n")
+	fmt.Fprintf(f, "%s", getSearchScripts())
+	if p.Name == HTMLIndexPage {
+		// links to the single page reference point to the index
+		fmt.Fprintf(f, "<SCRIPT src=\"js/anchors.js\"></SCRIPT>\n")
+		fmt.Fprintf(f, "<SCRIPT>(function(){var id=decodeURIComponent(location.hash.substring(1));var page=anchorPages[id];")
+		fmt.Fprintf(f, "if(page&&page!==\"%s\"){location.replace(page+\".html#\"+id);}})();</SCRIPT>\n", HTMLIndexPage)
+	}
+	fmt.Fprintf(f, "</BODY>\n</HTML>\n")
+}
+
+// generatePageNav lists the top level pages, with the sections of the current page
+func (h *HTMLPagesWriter) generatePageNav(current *htmlPage, pages []*htmlPage) string {
+	nav := "<UL>\n"
+	for _, p := range pages {
+		if p.Section.Level != 1 {
+			continue
+		}
+		class := "nav-level-1"
+		title := p.Title
+		if strings.Contains(p.Section.Link, "strong") {
+			class += " strong-nav"
+			title = "<STRONG>" + title + "</STRONG>"
+		}
+		if p == current {
+			class += " active"
+		}
+		nav += fmt.Sprintf(" <LI class=\"%s\"><A href=\"%s.html\" class=\"nav-item\">%s</A>%s</LI>\n",
+			class, p.Name, title, p.Section.Badges)
+		if p != current || len(p.Children) > 0 {
+			continue
+		}
+		for _, sub := range p.Section.SubSections {
+			nav += fmt.Sprintf("  <LI class=\"nav-level-2\"><A href=\"#%s\" class=\"nav-item\">%s</A></LI>\n", sub.Link, sub.Title)
+			for _, subsub := range sub.SubSections {
+				nav += fmt.Sprintf("  <LI class=\"nav-level-3\"><A href=\"#%s\" class=\"nav-item\">%s</A></LI>\n", subsub.Link, subsub.Title)
+			}
+		}
+	}
+	return nav + "</UL>\n"
+}
+
+// writeAnchors writes the page of every anchor of the single page reference
+func (h *HTMLPagesWriter) writeAnchors(anchors map[string]string) {
+	b, err := json.Marshal(anchors)
+	if err != nil {
+		panic(err)
+	}
+	js := fmt.Sprintf("var anchorPages=%s;\n", b)
+	if err := ioutil.WriteFile(filepath.Join(h.Dir, "js", "anchors.js"), []byte(js), 0644); err != nil {
+		panic(err)
+	}
+}
+
+// replacePageAnchors points links to anchors on other pages to these pages
+func replacePageAnchors(content, page string, anchors map[string]string) string {
+	return htmlAnchorPattern.ReplaceAllStringFunc(content, func(href string) string {
+		id := htmlAnchorPattern.FindStringSubmatch(href)[1]
+		if p, ok := anchors[id]; ok && p != page {
+			return fmt.Sprintf("href=\"%s\"", getPageURL(id, anchors))
+		}
+		return href
+	})
+}
+
+// getPageURL returns the link to an anchor on the page it is on
+func getPageURL(anchor string, anchors map[string]string) string {
+	return fmt.Sprintf("%s.html#%s", anchors[anchor], anchor)
+}
+
+// getPageName returns the link of a section without the markers of strong sections
+func getPageName(link string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(link, "-strong-"), "-strong-")
+}
+
+func readInclude(fn string) string {
+	if len(fn) == 0 {
+		return ""
+	}
+	content, err := ioutil.ReadFile(filepath.Join(api.IncludesDir, fn))
+	if err != nil {
+		fmt.Printf("Warning: Could not read %s: %v\n", fn, err)
+		return ""
+	}
+	return string(content)
+}
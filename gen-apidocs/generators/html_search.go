@@ -0,0 +1,252 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var SearchDepth = flag.Int("search-depth", 8,
+	"Number of nested fields followed from the resources for the field paths of the search index, 0 for none.")
+
+// Files of the search index and widget, next to the HTML reference
+const SearchIndexFile = "search.json"
+const SearchScriptFile = "search.js"
+
+// SearchDocument is a document of the search index. The fields are those of the lunr and
+// elasticlunr documents the index is built from in the browser, with id as ref.
+type SearchDocument struct {
+	ID string `json:"id"`
+	// resource, definition, field or operation
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	GVK         string `json:"gvk,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Anchor of the single page reference
+	Anchor string `json:"anchor"`
+	// Link relative to the reference, the anchor of the page it is on
+	URL string `json:"url"`
+}
+
+// addResourceDocuments adds the resource, its operations and the field paths from it
+func (h *HTMLWriter) addResourceDocuments(r *api.Resource, anchor string) {
+	d := r.Definition
+	h.SearchDocuments = append(h.SearchDocuments, &SearchDocument{
+		ID:          d.Key(),
+		Type:        "resource",
+		Title:       fmt.Sprintf("%s %s %s", r.Name, d.Version, d.GroupDisplayName()),
+		GVK:         getSearchGVK(d),
+		Description: GetFirstSentence(api.UnescapeAsterisks(d.Description())),
+		Anchor:      anchor,
+	})
+	h.addFieldDocuments(d, d, "", "", 0, map[*api.Definition]bool{})
+	for _, c := range d.OperationCategories {
+		for _, o := range c.Operations {
+			h.SearchDocuments = append(h.SearchDocuments, &SearchDocument{
+				ID:          o.ID,
+				Type:        "operation",
+				Title:       fmt.Sprintf("%s %s", o.Type.Name, r.Name),
+				GVK:         getSearchGVK(d),
+				Path:        o.GetDisplayHttp(),
+				Description: o.Description(),
+				Anchor:      getLink(o.Type.Name) + "-" + d.LinkID(),
+			})
+		}
+	}
+}
+
+// addFieldDocuments adds the paths of the fields of d, not following definitions of the
+// path again or beyond --search-depth. Fields of the resource link to its field table, nested
+// fields to the field tree of the resource if it is that deep, to the field table of d otherwise.
+func (h *HTMLWriter) addFieldDocuments(d, resource *api.Definition, prefix, treePrefix string, depth int, parents map[*api.Definition]bool) {
+	if depth >= *SearchDepth || parents[d] {
+		return
+	}
+	parents[d] = true
+	defer delete(parents, d)
+
+	for _, field := range d.Fields {
+		path := prefix + field.Name
+		treePath := treePrefix + field.Name
+		if field.HasComplexType() && !field.Definition.IsWrapper() && IsArray(field.Type) {
+			treePath += "[]"
+		}
+		anchor := getFieldAnchor(d, field)
+		if depth > 0 && depth < *FieldTreeDepth {
+			anchor = getFieldTreeAnchor(resource, treePath)
+		}
+		h.SearchDocuments = append(h.SearchDocuments, &SearchDocument{
+			ID:          resource.Key() + "." + path,
+			Type:        "field",
+			Title:       resource.Name + "." + path,
+			GVK:         getSearchGVK(resource),
+			Path:        path,
+			Description: GetFirstSentence(api.UnescapeAsterisks(field.Description)),
+			Anchor:      anchor,
+		})
+		if field.HasComplexType() {
+			h.addFieldDocuments(field.Definition, resource, path+".", treePath+".", depth+1, parents)
+		}
+	}
+}
+
+func (h *HTMLWriter) addDefinitionDocument(d *api.Definition, anchor string) {
+	h.SearchDocuments = append(h.SearchDocuments, &SearchDocument{
+		ID:          d.Key(),
+		Type:        "definition",
+		Title:       fmt.Sprintf("%s %s %s", d.Name, d.Version, d.GroupDisplayName()),
+		GVK:         getSearchGVK(d),
+		Description: GetFirstSentence(api.UnescapeAsterisks(d.Description())),
+		Anchor:      anchor,
+	})
+}
+
+// writeSearchIndex writes the documents, linked to the pages returned by getURL, to dir
+// and the widget script to jsDir
+func (h *HTMLWriter) writeSearchIndex(dir, jsDir string, getURL func(anchor string) string) {
+	for _, doc := range h.SearchDocuments {
+		doc.URL = getURL(doc.Anchor)
+	}
+	writeJSONFile(filepath.Join(dir, SearchIndexFile), h.SearchDocuments)
+	if err := ioutil.WriteFile(filepath.Join(jsDir, SearchScriptFile), []byte(searchScript), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Wrote %d documents to the search index\n", len(h.SearchDocuments))
+}
+
+// getSearchWidget returns the search box of the navigation
+func getSearchWidget() string {
+	widget := "<DIV class=\"search-widget\">\n"
+	widget += fmt.Sprintf(" <INPUT id=\"search-input\" type=\"search\" class=\"form-control\" placeholder=\"Search\" data-index=\"%s\">\n",
+		SearchIndexFile)
+	widget += " <UL id=\"search-results\"></UL>\n</DIV>\n"
+	return widget
+}
+
+// getSearchScripts returns the scripts of the search widget. lunr is optional, without it
+// titles and paths are matched by substrings.
+func getSearchScripts() string {
+	/*
+		Make sure the following script exists in kubernetes/website repo:
+		kubernetes/website/static/js/lunr-2.3.8.min.js
+	*/
+	return "<SCRIPT src=\"/js/lunr-2.3.8.min.js\"></SCRIPT>\n" +
+		fmt.Sprintf("<SCRIPT src=\"js/%s\"></SCRIPT>\n", SearchScriptFile)
+}
+
+// getFieldAnchor returns the id of the row of the field in the table of d
+func getFieldAnchor(d *api.Definition, field *api.Field) string {
+	return d.LinkID() + "-" + strings.ToLower(field.Name)
+}
+
+func getSearchGVK(d *api.Definition) string {
+	return fmt.Sprintf("%s %s", d.GroupVersion(), d.Name)
+}
+
+const searchScript = `(function() {
+  var input = document.getElementById("search-input");
+  var results = document.getElementById("search-results");
+  if (!input || !results) {
+    return;
+  }
+  var documents = null, byId = {}, index = null;
+
+  function load(callback) {
+    var request = new XMLHttpRequest();
+    request.onload = function() {
+      documents = JSON.parse(request.responseText);
+      documents.forEach(function(doc) { byId[doc.id] = doc; });
+      if (window.lunr) {
+        index = lunr(function() {
+          this.ref("id");
+          this.field("title", {boost: 10});
+          this.field("path", {boost: 5});
+          this.field("gvk");
+          this.field("description");
+          documents.forEach(function(doc) { this.add(doc); }, this);
+        });
+      }
+      callback();
+    };
+    request.open("GET", input.getAttribute("data-index"));
+    request.send();
+  }
+
+  function search(query) {
+    var q = query.toLowerCase();
+    // exact field paths and titles first, e.g. spec.template.spec.tolerations
+    var found = documents.filter(function(doc) {
+      return (doc.path && doc.path.toLowerCase() === q) || doc.title.toLowerCase() === q;
+    });
+    if (index) {
+      try {
+        index.search(query).forEach(function(r) { found.push(byId[r.ref]); });
+      } catch (e) {
+        // lunr query syntax errors, e.g. a trailing colon
+      }
+    }
+    documents.forEach(function(doc) {
+      if (doc.title.toLowerCase().indexOf(q) >= 0) {
+        found.push(doc);
+      }
+    });
+    return found.filter(function(doc, i) { return found.indexOf(doc) === i; }).slice(0, 20);
+  }
+
+  function show() {
+    var query = input.value.trim();
+    results.innerHTML = "";
+    if (query.length < 2) {
+      return;
+    }
+    search(query).forEach(function(doc) {
+      var item = document.createElement("LI");
+      var link = document.createElement("A");
+      link.href = doc.url;
+      link.textContent = doc.title;
+      link.title = doc.description || "";
+      item.appendChild(link);
+      var type = document.createElement("SMALL");
+      type.textContent = " " + doc.type;
+      item.appendChild(type);
+      results.appendChild(item);
+    });
+  }
+
+  input.addEventListener("input", function() {
+    if (documents === null) {
+      documents = [];
+      load(show);
+      return;
+    }
+    show();
+  });
+  input.addEventListener("keydown", function(e) {
+    var first = results.querySelector("A");
+    if (e.key === "Enter" && first) {
+      window.location.href = first.href;
+    }
+  });
+})();
+`
@@ -0,0 +1,81 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var FieldTreeDepth = flag.Int("field-tree-depth", 10,
+	"Number of nested field levels shown in the field tree of resources in the HTML reference, 0 for no tree.")
+
+// writeFieldTree writes the fields of a resource and their nested fields as expandable tree,
+// like kubectl explain --recursive
+func (h *HTMLWriter) writeFieldTree(w io.Writer, r *api.Resource) {
+	if *FieldTreeDepth <= 0 || len(r.Definition.Fields) == 0 {
+		return
+	}
+	d := r.Definition
+	fmt.Fprintf(w, "<H3 id=\"%s-field-tree\">%s Field Tree</H3>\n", d.LinkID(), r.Name)
+	fmt.Fprintf(w, "<DIV class=\"field-tree\">\n")
+	h.writeFieldTreeNodes(w, d, d, "", 1, map[*api.Definition]bool{})
+	fmt.Fprintf(w, "</DIV>\n")
+}
+
+// writeFieldTreeNodes writes the fields of d, not expanding definitions of the path again
+func (h *HTMLWriter) writeFieldTreeNodes(w io.Writer, d, resource *api.Definition, prefix string, depth int, parents map[*api.Definition]bool) {
+	parents[d] = true
+	defer delete(parents, d)
+
+	for _, field := range SortFields(d.Fields) {
+		path := prefix + field.Name
+		nested := field.HasComplexType() && !field.Definition.IsWrapper()
+		if nested && IsArray(field.Type) {
+			path += "[]"
+		}
+
+		fmt.Fprintf(w, "<DETAILS id=\"%s\"", getFieldTreeAnchor(resource, path))
+		if depth > 1 {
+			fmt.Fprintf(w, " style=\"margin-left: 1.5em\"")
+		}
+		fmt.Fprintf(w, "><SUMMARY><CODE>%s</CODE> <I>%s</I>", path, field.FullLink())
+		h.writeFieldBadges(w, field)
+		if nested && parents[field.Definition] {
+			fmt.Fprintf(w, " <SMALL>(recursive)</SMALL>")
+			nested = false
+		} else if nested && depth >= *FieldTreeDepth {
+			fmt.Fprintf(w, " <SMALL>(see %s)</SMALL>", field.Definition.HrefLink())
+			nested = false
+		}
+		fmt.Fprintf(w, "</SUMMARY>\n<P>%s</P>\n", field.DescriptionWithEntities)
+		if nested {
+			h.writeFieldTreeNodes(w, field.Definition, resource, path+".", depth+1, parents)
+		}
+		fmt.Fprintf(w, "</DETAILS>\n")
+	}
+}
+
+// getFieldTreeAnchor returns the id of a field path in the tree of a resource,
+// e.g. deployment-v1-apps-tree-spec.template.spec.containers[].ports[]
+func getFieldTreeAnchor(resource *api.Definition, path string) string {
+	return resource.LinkID() + "-tree-" + strings.ToLower(path)
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var JSONSchemaDir = flag.String("json-schema-dir", "",
+	"Directory the JSON schemas are written to, defaults to build/jsonschema/<release> in the work directory.")
+
+const JSONSchemaVersion = "http://json-schema.org/draft-07/schema#"
+const JSONSchemaCatalogFile = "catalog.json"
+
+const gvkExtension = "x-kubernetes-group-version-kind"
+const definitionsRefPrefix = "#/definitions/"
+const parametersRefPrefix = "#/parameters/"
+
+// Open-api definitions that accept more than their declared type
+const quantityDefinition = "io.k8s.apimachinery.pkg.api.resource.Quantity"
+const intOrStringFormat = "int-or-string"
+
+// JSONSchemaCatalogEntry maps a group version kind to the file of its schema
+type JSONSchemaCatalogEntry struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Schema     string `json:"schema"`
+}
+
+type JSONSchemaCatalog struct {
+	Release string                   `json:"release"`
+	Schemas []JSONSchemaCatalogEntry `json:"schemas"`
+}
+
+// GenerateJSONSchemas writes a standalone JSON schema for every group version kind of the release
+// and a catalog mapping apiVersion and kind to the schema files.
+func GenerateJSONSchemas(config *api.Config) {
+	dir := *JSONSchemaDir
+	if dir == "" {
+		dir = filepath.Join(api.BuildDir, "jsonschema", config.SpecVersion)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		panic(err)
+	}
+
+	definitions := map[string]interface{}{}
+	for _, doc := range api.LoadOpenApiSpec() {
+		for name, schema := range doc.Spec().Definitions {
+			definitions[name] = schemaToJSON(schema)
+		}
+	}
+
+	// quantities are written as strings or numbers, e.g. memory: 1Gi or cpu: 2
+	if quantity, ok := definitions[quantityDefinition].(map[string]interface{}); ok {
+		delete(quantity, "type")
+		quantity["oneOf"] = []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "number"},
+		}
+	}
+
+	catalog := JSONSchemaCatalog{Release: config.SpecVersion, Schemas: []JSONSchemaCatalogEntry{}}
+	for _, d := range config.Definitions.All {
+		group, version, kind, ok := GetGroupVersionKind(d)
+		if !ok {
+			continue
+		}
+		api_version := version
+		if group != "" {
+			api_version = group + "/" + version
+		}
+		fn := GetJSONSchemaFileName(group, version, kind)
+		writeJSONFile(filepath.Join(dir, fn), BuildJSONSchema(d, api_version, kind, definitions))
+		catalog.Schemas = append(catalog.Schemas, JSONSchemaCatalogEntry{
+			APIVersion: api_version,
+			Kind:       kind,
+			Schema:     fn,
+		})
+	}
+	sort.Slice(catalog.Schemas, func(i, j int) bool {
+		return catalog.Schemas[i].Schema < catalog.Schemas[j].Schema
+	})
+	writeJSONFile(filepath.Join(dir, JSONSchemaCatalogFile), catalog)
+	fmt.Printf("Wrote %d JSON schemas to %s\n", len(catalog.Schemas), dir)
+}
+
+// GetGroupVersionKind returns the group version kind of definitions of a single Kubernetes kind
+func GetGroupVersionKind(d *api.Definition) (string, string, string, bool) {
+	gvks, ok := d.Schema().Extensions[gvkExtension].([]interface{})
+	if !ok || len(gvks) != 1 {
+		return "", "", "", false
+	}
+	gvk, _ := gvks[0].(map[string]interface{})
+	group, _ := gvk["group"].(string)
+	version, _ := gvk["version"].(string)
+	kind, _ := gvk["kind"].(string)
+	return group, version, kind, kind == d.Name
+}
+
+// GetJSONSchemaFileName names schemas <kind>-<group>-<version>.json, e.g. deployment-apps-v1.json
+func GetJSONSchemaFileName(group, version, kind string) string {
+	parts := []string{strings.ToLower(kind)}
+	if group != "" {
+		parts = append(parts, strings.Split(group, ".")[0])
+	}
+	parts = append(parts, version)
+	return strings.Join(parts, "-") + ".json"
+}
+
+// BuildJSONSchema returns the schema of d with apiVersion and kind fixed, and the definitions
+// it refers to directly or indirectly copied to its own definitions.
+func BuildJSONSchema(d *api.Definition, api_version, kind string, definitions map[string]interface{}) map[string]interface{} {
+	schema, _ := schemaToJSON(d.Schema()).(map[string]interface{})
+	schema["$schema"] = JSONSchemaVersion
+	schema["title"] = api_version + " " + kind
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		if p, ok := properties["apiVersion"].(map[string]interface{}); ok {
+			p["enum"] = []string{api_version}
+		}
+		if p, ok := properties["kind"].(map[string]interface{}); ok {
+			p["enum"] = []string{kind}
+		}
+	}
+
+	referenced := map[string]interface{}{}
+	queue := collectRefs(schema, definitionsRefPrefix, []string{})
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := referenced[name]; ok {
+			continue
+		}
+		def, ok := definitions[name]
+		if !ok {
+			msg := fmt.Sprintf("Could not resolve $ref %s in the schema of %s %s", name, api_version, kind)
+			if !*api.AllowErrors {
+				panic(msg)
+			}
+			fmt.Printf("Warning: %s\n", msg)
+			def = map[string]interface{}{}
+		}
+		referenced[name] = def
+		queue = collectRefs(def, definitionsRefPrefix, queue)
+	}
+	if len(referenced) > 0 {
+		schema["definitions"] = referenced
+	}
+	return schema
+}
+
+// schemaToJSON converts an open-api schema to plain JSON values, replacing the int-or-string
+// format and quantities by the types they accept
+func schemaToJSON(schema spec.Schema) interface{} {
+	return relaxSchemaTypes(toJSONValue(schema))
+}
+
+// toJSONValue converts v to plain JSON values
+func toJSONValue(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		panic(err)
+	}
+	return value
+}
+
+func relaxSchemaTypes(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = relaxSchemaTypes(item)
+		}
+		if value["format"] == intOrStringFormat {
+			delete(value, "type")
+			value["oneOf"] = []interface{}{
+				map[string]interface{}{"type": "string"},
+				map[string]interface{}{"type": "integer"},
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = relaxSchemaTypes(item)
+		}
+	}
+	return v
+}
+
+// collectRefs appends the names in the $refs starting with prefix in v to names
+func collectRefs(v interface{}, prefix string, names []string) []string {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok && strings.HasPrefix(ref, prefix) {
+			names = append(names, strings.TrimPrefix(ref, prefix))
+		}
+		for _, item := range value {
+			names = collectRefs(item, prefix, names)
+		}
+	case []interface{}:
+		for _, item := range value {
+			names = collectRefs(item, prefix, names)
+		}
+	}
+	return names
+}
+
+func writeJSONFile(fn string, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(fn, append(b, '\n'), 0644); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,443 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var MarkdownDir = flag.String("markdown-dir", "",
+	"Directory the Markdown pages are written to, defaults to build/markdown in the work directory.")
+
+// Sections of the Markdown pages that are not resource categories
+const MarkdownDefinitionsSection = "definitions"
+const MarkdownOldVersionsSection = "old-versions"
+
+// MarkdownFrontMatter is the Hugo front matter of a page
+type MarkdownFrontMatter struct {
+	Title       string `yaml:"title"`
+	LinkTitle   string `yaml:"linkTitle,omitempty"`
+	Weight      int    `yaml:"weight"`
+	Description string `yaml:"description,omitempty"`
+	APIVersion  string `yaml:"api_version,omitempty"`
+	Kind        string `yaml:"kind,omitempty"`
+}
+
+// MarkdownWriter writes a Hugo section per resource category with a page per resource,
+// a section with the other definitions and one with the old versions.
+type MarkdownWriter struct {
+	Config *api.Config
+	Title  string
+	Dir    string
+
+	// page and anchor every definition is documented at, relative to Dir
+	pages   map[*api.Definition]string
+	anchors map[*api.Definition]string
+	section string
+	weight  int
+	count   int
+}
+
+func NewMarkdownWriter(config *api.Config, title string) DocWriter {
+	dir := *MarkdownDir
+	if dir == "" {
+		dir = filepath.Join(api.BuildDir, "markdown")
+	}
+	w := &MarkdownWriter{
+		Config:  config,
+		Title:   title,
+		Dir:     dir,
+		pages:   map[*api.Definition]string{},
+		anchors: map[*api.Definition]string{},
+	}
+
+	// same placement as in writeDocs
+	for _, c := range config.ResourceCategories {
+		for _, r := range c.Resources {
+			if r.Definition != nil {
+				w.addPage(r.Definition, c.Include)
+			}
+		}
+	}
+	for _, d := range config.Definitions.All {
+		if _, ok := w.pages[d]; ok || d.IsInlined {
+			continue
+		}
+		if d.IsOldVersion {
+			w.addPage(d, MarkdownOldVersionsSection)
+		} else if !d.InToc {
+			w.addPage(d, MarkdownDefinitionsSection)
+		}
+	}
+	return w
+}
+
+func (m *MarkdownWriter) addPage(d *api.Definition, section string) {
+	page := section + "/" + d.LinkID()
+	m.pages[d] = page
+	m.anchors[d] = d.LinkID()
+	for _, i := range d.Inline {
+		if _, ok := m.pages[i]; !ok {
+			m.pages[i] = page
+			m.anchors[i] = i.LinkID()
+		}
+	}
+}
+
+func (m *MarkdownWriter) Extension() string {
+	return ".md"
+}
+
+func (m *MarkdownWriter) DefaultStaticContent(title string) string {
+	return fmt.Sprintf("# %s {#%s}\n", title, getLink(title))
+}
+
+func (m *MarkdownWriter) WriteOverview() {
+	f := m.createPage("_index", MarkdownFrontMatter{Title: m.Title})
+	defer f.Close()
+	m.writeStaticContent(f, "Overview", "_overview.md")
+}
+
+func (m *MarkdownWriter) WriteAPIGroupVersions(gvs api.GroupVersions) {
+	f := m.createPage("api-groups", MarkdownFrontMatter{Title: "API Groups"})
+	defer f.Close()
+
+	fmt.Fprintf(f, "%s\nThe API Groups and their versions are summarized in the following table.\n\n", m.DefaultStaticContent("API Groups"))
+	fmt.Fprintf(f, "| Group | Versions |\n| --- | --- |\n")
+	groups := api.ApiGroups{}
+	for group := range gvs {
+		groups = append(groups, api.ApiGroup(group))
+	}
+	sort.Sort(groups)
+	for _, group := range groups {
+		versionList := gvs[group.String()]
+		sort.Sort(versionList)
+		versions := []string{}
+		for _, v := range versionList {
+			versions = append(versions, "`"+v.String()+"`")
+		}
+		fmt.Fprintf(f, "| `%s` | %s |\n", group, strings.Join(versions, ", "))
+	}
+}
+
+func (m *MarkdownWriter) WriteResourceCategory(name, file string) {
+	m.writeSection(file, name, "_"+file+".md")
+}
+
+func (m *MarkdownWriter) WriteDefinitionsOverview() {
+	m.writeSection(MarkdownDefinitionsSection, "Definitions", "_definitions.md")
+}
+
+func (m *MarkdownWriter) WriteOldVersionsOverview() {
+	m.writeSection(MarkdownOldVersionsSection, "Old API Versions", "_oldversions.md")
+}
+
+// writeSection starts a section with its index page
+func (m *MarkdownWriter) writeSection(section, title, static string) {
+	m.section = section
+	f := m.createPage(section+"/_index", MarkdownFrontMatter{Title: title})
+	defer f.Close()
+	m.writeStaticContent(f, title, static)
+}
+
+// writeStaticContent copies the Markdown file from the sections directory if there is one
+func (m *MarkdownWriter) writeStaticContent(w io.Writer, title, fn string) {
+	content, err := ioutil.ReadFile(filepath.Join(api.SectionsDir, fn))
+	if err == nil {
+		w.Write(content)
+		return
+	}
+	if !os.IsNotExist(err) {
+		panic(fmt.Sprintf("Could not read file %s %v", fn, err))
+	}
+	fmt.Fprint(w, m.DefaultStaticContent(title))
+}
+
+func (m *MarkdownWriter) WriteResource(r *api.Resource) {
+	d := r.Definition
+	page := m.pages[d]
+	if page == "" {
+		page = m.section + "/" + d.LinkID()
+	}
+	f := m.createPage(page, MarkdownFrontMatter{
+		Title:       fmt.Sprintf("%s %s %s", r.Name, d.Version, d.GroupDisplayName()),
+		LinkTitle:   r.Name,
+		Description: GetFirstSentence(api.UnescapeAsterisks(d.Description())),
+		APIVersion:  d.GroupVersion(),
+		Kind:        d.Name,
+	})
+	defer f.Close()
+
+	fmt.Fprintf(f, "# %s %s %s {#%s}\n\n", r.Name, d.Version, d.GroupDisplayName(), d.LinkID())
+	m.writeGroupVersionKind(f, d)
+	if r.DescriptionWarning != "" {
+		fmt.Fprintf(f, "{{< warning >}}\n%s\n{{< /warning >}}\n\n", m.replaceAnchors(r.DescriptionWarning))
+	}
+	if r.DescriptionNote != "" {
+		fmt.Fprintf(f, "{{< note >}}\n%s\n{{< /note >}}\n\n", m.replaceAnchors(r.DescriptionNote))
+	}
+	m.writeSample(f, d)
+	m.writeResourceLinks(f, r)
+	m.writeOtherVersions(f, d)
+	m.writeAppearsIn(f, d)
+	m.writeFields(f, d)
+
+	for _, i := range d.Inline {
+		fmt.Fprintf(f, "## %s %s %s {#%s}\n\n", i.Name, i.Version, i.Group, i.LinkID())
+		m.writeAppearsIn(f, i)
+		m.writeFields(f, i)
+	}
+
+	for _, c := range d.OperationCategories {
+		if len(c.Operations) == 0 {
+			continue
+		}
+		fmt.Fprintf(f, "## %s {#%s}\n\n", c.Name, getLink(c.Name)+"-"+d.LinkID())
+		for _, o := range c.Operations {
+			m.writeOperation(f, d, o)
+		}
+	}
+}
+
+func (m *MarkdownWriter) WriteDefinition(d *api.Definition) {
+	page := m.pages[d]
+	if page == "" {
+		page = m.section + "/" + d.LinkID()
+	}
+	f := m.createPage(page, MarkdownFrontMatter{
+		Title:       fmt.Sprintf("%s %s %s", d.Name, d.Version, d.GroupDisplayName()),
+		LinkTitle:   d.Name,
+		Description: GetFirstSentence(api.UnescapeAsterisks(d.Description())),
+	})
+	defer f.Close()
+
+	fmt.Fprintf(f, "# %s %s %s {#%s}\n\n", d.Name, d.Version, d.GroupDisplayName(), d.LinkID())
+	m.writeGroupVersionKind(f, d)
+	m.writeOtherVersions(f, d)
+	m.writeAppearsIn(f, d)
+	m.writeFields(f, d)
+}
+
+func (m *MarkdownWriter) Finalize() {
+	fmt.Printf("Wrote %d Markdown pages to %s\n", m.count, m.Dir)
+}
+
+// createPage creates the file of page, relative to the output directory, and writes its front matter
+func (m *MarkdownWriter) createPage(page string, front MarkdownFrontMatter) *os.File {
+	fn := filepath.Join(m.Dir, page+m.Extension())
+	if err := os.MkdirAll(filepath.Dir(fn), os.ModePerm); err != nil {
+		panic(err)
+	}
+	f, err := os.Create(fn)
+	if err != nil {
+		os.Stderr.WriteString(fmt.Sprintf("%v", err))
+		os.Exit(1)
+	}
+	m.weight += 10
+	m.count++
+	front.Weight = m.weight
+	b, err := yaml.Marshal(front)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintf(f, "---\n%s---\n\n", b)
+	return f
+}
+
+func (m *MarkdownWriter) writeGroupVersionKind(w io.Writer, d *api.Definition) {
+	fmt.Fprintf(w, "| Group | Version | Kind |\n| --- | --- | --- |\n")
+	fmt.Fprintf(w, "| `%s` | `%s` | `%s` |\n\n", d.GroupDisplayName(), d.Version, d.Name)
+	fmt.Fprintf(w, "%s\n\n", api.UnescapeAsterisks(d.Description()))
+}
+
+// getLink returns a link to the page of d, relative to the pages of the sections
+func (m *MarkdownWriter) getLink(d *api.Definition) string {
+	page, ok := m.pages[d]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("../../%s/#%s", page, m.anchors[d])
+}
+
+// replaceAnchors points the links to anchors of the single page docs in the config to the pages
+func (m *MarkdownWriter) replaceAnchors(s string) string {
+	for d, anchor := range m.anchors {
+		s = strings.Replace(s, `href="#`+anchor+`"`, `href="`+m.getLink(d)+`"`, -1)
+	}
+	return s
+}
+
+func (m *MarkdownWriter) getFieldType(field *api.Field) string {
+	if field.Type == "" {
+		return ""
+	}
+	if !field.HasComplexType() {
+		return "*" + field.Type + "*"
+	}
+	link := m.getLink(field.Definition)
+	if link == "" {
+		return "*" + field.Type + "*"
+	}
+	return "*" + strings.Replace(field.Type, field.Definition.Name, "["+field.Definition.Name+"]("+link+")", 1) + "*"
+}
+
+func (m *MarkdownWriter) writeOtherVersions(w io.Writer, d *api.Definition) {
+	if d.OtherVersions.Len() == 0 {
+		return
+	}
+	versions := []string{}
+	for _, v := range d.OtherVersions {
+		if link := m.getLink(v); link != "" {
+			versions = append(versions, fmt.Sprintf("[%s](%s)", v.Version, link))
+		} else {
+			versions = append(versions, v.Version.String())
+		}
+	}
+	fmt.Fprintf(w, "Other API versions of this object exist: %s\n\n", strings.Join(versions, ", "))
+}
+
+func (m *MarkdownWriter) writeResourceLinks(w io.Writer, r *api.Resource) {
+	if concept, ok := GetConceptLink(r); ok {
+		fmt.Fprintf(w, "**Concepts:** [%s](%s)\n\n", concept.Title, concept.URL)
+	}
+	tasks := GetRelatedTaskLinks(r)
+	if len(tasks) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "**Related tasks:**\n\n")
+	for _, t := range tasks {
+		fmt.Fprintf(w, "- [%s](%s)\n", t.Title, t.URL)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (m *MarkdownWriter) writeAppearsIn(w io.Writer, d *api.Definition) {
+	if d.AppearsIn.Len() == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Appears In:\n\n")
+	for _, a := range d.AppearsIn {
+		title := fmt.Sprintf("%s %s %s", a.Name, a.Version, a.GroupDisplayName())
+		if link := m.getLink(a); link != "" {
+			title = fmt.Sprintf("[%s](%s)", title, link)
+		}
+		fmt.Fprintf(w, "- %s\n", title)
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (m *MarkdownWriter) writeFields(w io.Writer, d *api.Definition) {
+	if len(d.Fields) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "| Field | Description |\n| --- | --- |\n")
+	for _, field := range d.Fields {
+		fmt.Fprintf(w, "| `%s`<br />%s", field.Name, m.getFieldType(field))
+		if field.PatchStrategy != "" {
+			fmt.Fprintf(w, "<br />**patch strategy**: *%s*", field.PatchStrategy)
+		}
+		if field.PatchMergeKey != "" {
+			fmt.Fprintf(w, "<br />**patch merge key**: *%s*", field.PatchMergeKey)
+		}
+		fmt.Fprintf(w, " | %s |\n", getMarkdownCell(field.Description))
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (m *MarkdownWriter) writeParams(w io.Writer, title string, params api.Fields) {
+	fmt.Fprintf(w, "#### %s\n\n| Parameter | Description |\n| --- | --- |\n", title)
+	for _, p := range params {
+		fmt.Fprintf(w, "| `%s`<br />%s | %s |\n", p.Name, m.getFieldType(p), getMarkdownCell(p.Description))
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+func (m *MarkdownWriter) writeOperation(w io.Writer, d *api.Definition, o *api.Operation) {
+	fmt.Fprintf(w, "### %s {#%s}\n\n", o.Type.Name, getLink(o.Type.Name)+"-"+d.LinkID())
+	m.writeExamples(w, "request", o.GetExampleRequests())
+	m.writeExamples(w, "response", o.GetExampleResponses())
+	fmt.Fprintf(w, "%s\n\n#### HTTP Request\n\n`%s`\n\n", o.Description(), o.GetDisplayHttp())
+
+	if o.PathParams.Len() > 0 {
+		m.writeParams(w, "Path Parameters", o.PathParams)
+	}
+	if o.QueryParams.Len() > 0 {
+		m.writeParams(w, "Query Parameters", o.QueryParams)
+	}
+	if o.BodyParams.Len() > 0 {
+		m.writeParams(w, "Body Parameters", o.BodyParams)
+	}
+	if o.HttpResponses.Len() == 0 {
+		return
+	}
+	responses := append(api.HttpResponses{}, o.HttpResponses...)
+	sort.Slice(responses, func(i, j int) bool {
+		return strings.Compare(responses[i].Name, responses[j].Name) < 0
+	})
+	fmt.Fprintf(w, "#### Response\n\n| Code | Description |\n| --- | --- |\n")
+	for _, r := range responses {
+		fmt.Fprintf(w, "| %s<br />%s | %s |\n", r.Name, m.getFieldType(&r.Field), getMarkdownCell(r.Field.Description))
+	}
+	fmt.Fprintf(w, "\n")
+}
+
+// writeSample writes the samples of a resource as tabs of the website tabs shortcode
+func (m *MarkdownWriter) writeSample(w io.Writer, d *api.Definition) {
+	if d.Sample.Sample == "" {
+		return
+	}
+	if d.Sample.Note != "" {
+		fmt.Fprintf(w, "%s\n\n", d.Sample.Note)
+	}
+	fmt.Fprintf(w, "{{< tabs name=\"sample-%s\" >}}\n", d.LinkID())
+	for _, s := range d.GetSamples() {
+		tab := strings.Split(s.Tab, ":")[1]
+		lang := strings.Split(strings.Split(s.Type, ":")[1], "_")[1]
+		fmt.Fprintf(w, "{{< tab name=\"%s\" codelang=\"%s\" >}}\n%s\n{{< /tab >}}\n", tab, lang, s.Text)
+	}
+	fmt.Fprintf(w, "{{< /tabs >}}\n\n")
+}
+
+func (m *MarkdownWriter) writeExamples(w io.Writer, kind string, examples []api.ExampleText) {
+	if len(examples) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "{{< tabs name=\"%s-%s\" >}}\n", kind, examples[0].Msg)
+	for _, e := range examples {
+		tab := strings.Split(e.Tab, ":")[1]
+		lang := strings.Split(strings.Split(e.Type, ":")[1], "_")[1]
+		fmt.Fprintf(w, "{{< tab name=\"%s %s\" codelang=\"%s\" >}}\n%s\n{{< /tab >}}\n", tab, kind, lang, e.Text)
+	}
+	fmt.Fprintf(w, "{{< /tabs >}}\n\n")
+}
+
+// getMarkdownCell keeps a description on one line of a table
+func getMarkdownCell(description string) string {
+	description = strings.Join(strings.Fields(api.UnescapeAsterisks(description)), " ")
+	return strings.Replace(description, "|", "\\|", -1)
+}
@@ -0,0 +1,447 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ModelFile = flag.String("model-file", "",
+	"File the JSON model of the loaded API specs is written to, defaults to build/model.json in the work directory.")
+
+// Version of the JSON model format, increased on incompatible changes
+const ModelFormatVersion = 1
+
+// Model is the resolved API config written by the json output. Definitions are referred to
+// by their keys, <group>.<version>.<kind>, and operations by their IDs.
+type Model struct {
+	FormatVersion      int                     `json:"format_version"`
+	SpecTitle          string                  `json:"spec_title"`
+	SpecVersion        string                  `json:"spec_version"`
+	Groups             []ModelGroup            `json:"groups"`
+	ResourceCategories []ModelResourceCategory `json:"resource_categories"`
+	Definitions        []ModelDefinition       `json:"definitions"`
+	Operations         []ModelOperation        `json:"operations"`
+}
+
+type ModelGroup struct {
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"`
+	Versions []string `json:"versions"`
+}
+
+type ModelResourceCategory struct {
+	Name      string          `json:"name"`
+	File      string          `json:"file"`
+	Resources []ModelResource `json:"resources"`
+}
+
+type ModelResource struct {
+	Name               string   `json:"name"`
+	Group              string   `json:"group"`
+	Version            string   `json:"version"`
+	Definition         string   `json:"definition,omitempty"`
+	DescriptionWarning string   `json:"description_warning,omitempty"`
+	DescriptionNote    string   `json:"description_note,omitempty"`
+	ConceptGuide       string   `json:"concept_guide,omitempty"`
+	RelatedTasks       []string `json:"related_tasks,omitempty"`
+}
+
+type ModelDefinition struct {
+	Key                 string                   `json:"key"`
+	OpenApiName         string                   `json:"openapi_name,omitempty"`
+	Name                string                   `json:"name"`
+	Group               string                   `json:"group"`
+	GroupFullName       string                   `json:"group_full_name"`
+	Version             string                   `json:"version"`
+	Kind                string                   `json:"kind"`
+	LinkID              string                   `json:"link_id"`
+	Description         string                   `json:"description"`
+	Resource            string                   `json:"resource,omitempty"`
+	Namespaced          bool                     `json:"namespaced"`
+	InToc               bool                     `json:"in_toc"`
+	Inlined             bool                     `json:"inlined"`
+	OldVersion          bool                     `json:"old_version"`
+	Deprecated          bool                     `json:"deprecated"`
+	Maturity            api.Maturity             `json:"maturity"`
+	Fields              []ModelField             `json:"fields"`
+	Inline              []string                 `json:"inline"`
+	AppearsIn           []string                 `json:"appears_in"`
+	OtherVersions       []string                 `json:"other_versions"`
+	OperationCategories []ModelOperationCategory `json:"operation_categories"`
+	Sample              *ModelSample             `json:"sample,omitempty"`
+	History             *ModelHistory            `json:"history,omitempty"`
+}
+
+type ModelSample struct {
+	Note   string `json:"note,omitempty"`
+	Sample string `json:"sample"`
+}
+
+type ModelField struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"`
+	Definition    string            `json:"definition,omitempty"`
+	Description   string            `json:"description"`
+	Required      bool              `json:"required"`
+	Format        string            `json:"format,omitempty"`
+	Pattern       string            `json:"pattern,omitempty"`
+	PatchStrategy string            `json:"patch_strategy,omitempty"`
+	PatchMergeKey string            `json:"patch_merge_key,omitempty"`
+	Access        api.FieldAccess   `json:"access,omitempty"`
+	Maturity      api.Maturity      `json:"maturity,omitempty"`
+	Deprecated    bool              `json:"deprecated"`
+	Constraints   *ModelConstraints `json:"constraints,omitempty"`
+	History       *ModelHistory     `json:"history,omitempty"`
+}
+
+// ModelHistory are the releases a definition or field appeared, was deprecated or removed in
+type ModelHistory struct {
+	Since        string `json:"since,omitempty"`
+	DeprecatedIn string `json:"deprecated_in,omitempty"`
+	RemovedIn    string `json:"removed_in,omitempty"`
+}
+
+// ModelConstraints are the constraints found in a field description or schema, each with its confidence
+type ModelConstraints struct {
+	Default                 interface{} `json:"default,omitempty"`
+	DefaultConfidence       string      `json:"default_confidence,omitempty"`
+	AllowedValues           []string    `json:"allowed_values,omitempty"`
+	AllowedValuesConfidence string      `json:"allowed_values_confidence,omitempty"`
+	Minimum                 *int64      `json:"minimum,omitempty"`
+	Maximum                 *int64      `json:"maximum,omitempty"`
+	BoundsConfidence        string      `json:"bounds_confidence,omitempty"`
+}
+
+type ModelOperationCategory struct {
+	Name       string   `json:"name"`
+	Operations []string `json:"operations"`
+}
+
+// ModelParameter is a parameter of an operation, typed by its schema for body parameters
+type ModelParameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Definition  string `json:"definition,omitempty"`
+	Description string `json:"description"`
+	Required    bool   `json:"required"`
+}
+
+type ModelOperation struct {
+	ID          string             `json:"id"`
+	Type        string             `json:"type"`
+	Method      string             `json:"method"`
+	Path        string             `json:"path"`
+	Definition  string             `json:"definition,omitempty"`
+	Description string             `json:"description"`
+	PathParams  []ModelParameter   `json:"path_params"`
+	QueryParams []ModelParameter   `json:"query_params"`
+	BodyParams  []ModelParameter   `json:"body_params"`
+	Responses   []ModelResponse    `json:"responses"`
+	Examples    []ModelExampleText `json:"examples"`
+}
+
+type ModelResponse struct {
+	Code        string `json:"code"`
+	Type        string `json:"type"`
+	Definition  string `json:"definition,omitempty"`
+	Description string `json:"description"`
+}
+
+type ModelExampleText struct {
+	// request or response
+	Kind     string `json:"kind"`
+	Tab      string `json:"tab"`
+	Language string `json:"language"`
+	Title    string `json:"title"`
+	Text     string `json:"text"`
+}
+
+// GenerateModel writes the loaded config as JSON model
+func GenerateModel(config *api.Config) {
+	config.LoadHistory()
+	fn := *ModelFile
+	if fn == "" {
+		fn = filepath.Join(api.BuildDir, "model.json")
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), os.ModePerm); err != nil {
+		panic(err)
+	}
+	model := BuildModel(config)
+	writeJSONFile(fn, model)
+	fmt.Printf("Wrote %d definitions and %d operations to %s\n", len(model.Definitions), len(model.Operations), fn)
+}
+
+// BuildModel returns the model of the config with definitions sorted by key and operations by ID
+func BuildModel(config *api.Config) *Model {
+	model := &Model{
+		FormatVersion:      ModelFormatVersion,
+		SpecTitle:          config.SpecTitle,
+		SpecVersion:        config.SpecVersion,
+		Groups:             []ModelGroup{},
+		ResourceCategories: []ModelResourceCategory{},
+		Definitions:        []ModelDefinition{},
+		Operations:         []ModelOperation{},
+	}
+
+	groups := api.ApiGroups{}
+	for group := range config.Definitions.GroupVersions {
+		groups = append(groups, api.ApiGroup(group))
+	}
+	sort.Sort(groups)
+	for _, group := range groups {
+		versionList := config.Definitions.GroupVersions[group.String()]
+		sort.Sort(versionList)
+		g := ModelGroup{Name: group.String(), FullName: config.GroupMap[group.String()], Versions: []string{}}
+		for _, v := range versionList {
+			g.Versions = append(g.Versions, v.String())
+		}
+		model.Groups = append(model.Groups, g)
+	}
+
+	for _, c := range config.ResourceCategories {
+		category := ModelResourceCategory{Name: c.Name, File: c.Include, Resources: []ModelResource{}}
+		for _, r := range c.Resources {
+			category.Resources = append(category.Resources, ModelResource{
+				Name:               r.Name,
+				Group:              r.Group,
+				Version:            r.Version,
+				Definition:         getModelKey(r.Definition),
+				DescriptionWarning: r.DescriptionWarning,
+				DescriptionNote:    r.DescriptionNote,
+				ConceptGuide:       r.ConceptGuide,
+				RelatedTasks:       r.RelatedTasks,
+			})
+		}
+		model.ResourceCategories = append(model.ResourceCategories, category)
+	}
+
+	keys := []string{}
+	for key := range config.Definitions.All {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		model.Definitions = append(model.Definitions, buildModelDefinition(config.Definitions.All[key]))
+	}
+
+	ids := []string{}
+	for id := range config.Operations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		model.Operations = append(model.Operations, buildModelOperation(config.Operations[id]))
+	}
+	return model
+}
+
+func buildModelDefinition(d *api.Definition) ModelDefinition {
+	m := ModelDefinition{
+		Key:                 d.Key(),
+		OpenApiName:         d.OpenApiName,
+		Name:                d.Name,
+		Group:               d.Group.String(),
+		GroupFullName:       d.GroupFullName,
+		Version:             d.Version.String(),
+		Kind:                string(d.Kind),
+		LinkID:              d.LinkID(),
+		Description:         api.UnescapeAsterisks(d.Description()),
+		Resource:            d.Resource,
+		Namespaced:          d.Namespaced,
+		InToc:               d.InToc,
+		Inlined:             d.IsInlined,
+		OldVersion:          d.IsOldVersion,
+		Deprecated:          d.Deprecated,
+		Maturity:            d.Maturity(),
+		Fields:              buildModelFields(d.Fields),
+		Inline:              getModelKeys(d.Inline),
+		AppearsIn:           getModelKeys(d.AppearsIn),
+		OtherVersions:       getModelKeys(d.OtherVersions),
+		OperationCategories: []ModelOperationCategory{},
+		History:             buildModelHistory(d.History),
+	}
+	if d.Sample.Sample != "" {
+		m.Sample = &ModelSample{Note: d.Sample.Note, Sample: d.Sample.Sample}
+	}
+	for _, c := range d.OperationCategories {
+		if len(c.Operations) == 0 {
+			continue
+		}
+		category := ModelOperationCategory{Name: c.Name, Operations: []string{}}
+		for _, o := range c.Operations {
+			category.Operations = append(category.Operations, o.ID)
+		}
+		m.OperationCategories = append(m.OperationCategories, category)
+	}
+	return m
+}
+
+func buildModelHistory(history api.ReleaseHistory) *ModelHistory {
+	if history.IsEmpty() {
+		return nil
+	}
+	return &ModelHistory{Since: history.Since, DeprecatedIn: history.DeprecatedIn, RemovedIn: history.RemovedIn}
+}
+
+// getModelConfidence leaves out the confidence of constraints not found
+func getModelConfidence(c api.Confidence) string {
+	if c == api.ConfidenceNone {
+		return ""
+	}
+	return c.String()
+}
+
+func buildModelFields(fields api.Fields) []ModelField {
+	m := []ModelField{}
+	for _, f := range fields {
+		field := ModelField{
+			Name:          f.Name,
+			Type:          f.Type,
+			Definition:    getModelKey(f.Definition),
+			Description:   api.UnescapeAsterisks(f.Description),
+			Required:      f.SchemaRequired,
+			Format:        f.Format,
+			Pattern:       f.Pattern,
+			PatchStrategy: f.PatchStrategy,
+			PatchMergeKey: f.PatchMergeKey,
+			Access:        f.Access,
+			Maturity:      f.Maturity,
+			Deprecated:    f.Deprecated,
+			History:       buildModelHistory(f.History),
+		}
+		if c := f.Constraints; !c.IsEmpty() {
+			field.Constraints = &ModelConstraints{
+				Default:                 c.Default,
+				DefaultConfidence:       getModelConfidence(c.DefaultConfidence),
+				AllowedValues:           c.AllowedValues,
+				AllowedValuesConfidence: getModelConfidence(c.AllowedValuesConfidence),
+				Minimum:                 c.Minimum,
+				Maximum:                 c.Maximum,
+				BoundsConfidence:        getModelConfidence(c.BoundsConfidence),
+			}
+		}
+		m = append(m, field)
+	}
+	return m
+}
+
+// buildModelParameters returns the parameters of o for the fields built from them,
+// taking the type, format and required flag from the spec
+func buildModelParameters(o *api.Operation, fields api.Fields) []ModelParameter {
+	parameters := map[string]spec.Parameter{}
+	for _, p := range o.Parameters() {
+		parameters[p.Name] = p
+	}
+	m := []ModelParameter{}
+	for _, f := range fields {
+		p := parameters[f.Name]
+		parameter := ModelParameter{
+			Name:        f.Name,
+			In:          p.In,
+			Type:        f.Type,
+			Format:      p.Format,
+			Definition:  getModelKey(f.Definition),
+			Description: f.Description,
+			Required:    p.Required,
+		}
+		if p.Schema == nil {
+			parameter.Type = p.Type
+			if p.Type == "array" && p.Items != nil {
+				parameter.Type = p.Items.Type + " array"
+			}
+		}
+		m = append(m, parameter)
+	}
+	return m
+}
+
+func buildModelOperation(o *api.Operation) ModelOperation {
+	m := ModelOperation{
+		ID:          o.ID,
+		Type:        o.Type.Name,
+		Method:      o.HttpMethod,
+		Path:        o.Path,
+		Definition:  getModelKey(o.Definition),
+		Description: o.Description(),
+		PathParams:  buildModelParameters(o, o.PathParams),
+		QueryParams: buildModelParameters(o, o.QueryParams),
+		BodyParams:  buildModelParameters(o, o.BodyParams),
+		Responses:   []ModelResponse{},
+		Examples:    []ModelExampleText{},
+	}
+	for _, r := range o.HttpResponses {
+		m.Responses = append(m.Responses, ModelResponse{
+			Code:        r.Code,
+			Type:        r.Type,
+			Definition:  getModelKey(r.Definition),
+			Description: r.Description,
+		})
+	}
+	sort.Slice(m.Responses, func(i, j int) bool {
+		return m.Responses[i].Code < m.Responses[j].Code
+	})
+	// examples are only generated for operations of definitions
+	if o.Definition == nil {
+		return m
+	}
+	m.Examples = append(m.Examples, buildModelExamples("request", o.GetExampleRequests())...)
+	m.Examples = append(m.Examples, buildModelExamples("response", o.GetExampleResponses())...)
+	return m
+}
+
+// buildModelExamples strips the bdocs prefixes of tab and language
+func buildModelExamples(kind string, examples []api.ExampleText) []ModelExampleText {
+	m := []ModelExampleText{}
+	for _, e := range examples {
+		lang := e.Type[strings.Index(e.Type, ":")+1:]
+		m = append(m, ModelExampleText{
+			Kind:     kind,
+			Tab:      e.Tab[strings.Index(e.Tab, ":")+1:],
+			Language: lang[strings.Index(lang, "_")+1:],
+			Title:    e.Msg,
+			Text:     e.Text,
+		})
+	}
+	return m
+}
+
+func getModelKey(d *api.Definition) string {
+	if d == nil {
+		return ""
+	}
+	return d.Key()
+}
+
+func getModelKeys(definitions api.SortDefinitionsByName) []string {
+	keys := []string{}
+	for _, d := range definitions {
+		keys = append(keys, d.Key())
+	}
+	return keys
+}
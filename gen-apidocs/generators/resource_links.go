@@ -0,0 +1,141 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var WebsiteURL = flag.String("website-url", "https://kubernetes.io",
+	"Base URL the concept_guide and related_tasks paths of resources are resolved against.")
+var WebsiteRoot = flag.String("website-root", os.Getenv("K8S_WEBROOT"),
+	"Local checkout of the website the concept_guide and related_tasks paths are checked in, defaults to $K8S_WEBROOT.")
+
+// Language directory of the website content the paths are looked up in
+const websiteLanguage = "en"
+
+// Links are checked once for all outputs
+var resourceLinksChecked = false
+
+// ResourceLink is a link to a page of the website
+type ResourceLink struct {
+	Title string
+	URL   string
+}
+
+// GetConceptLink returns the link to the concept guide of r
+func GetConceptLink(r *api.Resource) (ResourceLink, bool) {
+	if r.ConceptGuide == "" {
+		return ResourceLink{}, false
+	}
+	return getWebsiteLink(r.ConceptGuide), true
+}
+
+// GetRelatedTaskLinks returns the links to the related tasks of r
+func GetRelatedTaskLinks(r *api.Resource) []ResourceLink {
+	links := []ResourceLink{}
+	for _, t := range r.RelatedTasks {
+		links = append(links, getWebsiteLink(t))
+	}
+	return links
+}
+
+// CheckResourceLinks warns about concept guides and related tasks not found in --website-root
+func CheckResourceLinks(config *api.Config) {
+	if *WebsiteRoot == "" || resourceLinksChecked {
+		return
+	}
+	resourceLinksChecked = true
+	for _, c := range config.ResourceCategories {
+		for _, r := range c.Resources {
+			paths := r.RelatedTasks
+			if r.ConceptGuide != "" {
+				paths = append([]string{r.ConceptGuide}, paths...)
+			}
+			for _, p := range paths {
+				if _, ok := findWebsitePage(p); !ok && !isAbsoluteURL(p) {
+					fmt.Printf("Warning: Could not find %s of %s in %s\n", p, r.Name, *WebsiteRoot)
+				}
+			}
+		}
+	}
+}
+
+// getWebsiteLink resolves path against --website-url, titled after the page in --website-root
+// if it is found there, after the last path element otherwise
+func getWebsiteLink(path string) ResourceLink {
+	link := ResourceLink{URL: path}
+	if !isAbsoluteURL(path) {
+		link.URL = strings.TrimSuffix(*WebsiteURL, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+	if fn, ok := findWebsitePage(path); ok {
+		link.Title = getWebsitePageTitle(fn)
+	}
+	if link.Title == "" {
+		name := filepath.Base(strings.TrimSuffix(strings.SplitN(path, "#", 2)[0], "/"))
+		link.Title = strings.Title(strings.Replace(name, "-", " ", -1))
+	}
+	return link
+}
+
+// findWebsitePage returns the content file of a path of the website, e.g.
+// content/en/docs/concepts/workloads/controllers/deployment.md for /docs/concepts/workloads/controllers/deployment/
+func findWebsitePage(path string) (string, bool) {
+	if *WebsiteRoot == "" || isAbsoluteURL(path) {
+		return "", false
+	}
+	page := strings.Trim(strings.SplitN(path, "#", 2)[0], "/")
+	dir := filepath.Join(*WebsiteRoot, "content", websiteLanguage, filepath.FromSlash(page))
+	for _, fn := range []string{dir + ".md", filepath.Join(dir, "_index.md"), filepath.Join(dir, "index.md")} {
+		if _, err := os.Stat(fn); err == nil {
+			return fn, true
+		}
+	}
+	return "", false
+}
+
+// getWebsitePageTitle returns the title in the front matter of a content file
+func getWebsitePageTitle(fn string) string {
+	content, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return ""
+	}
+	parts := strings.SplitN(string(content), "---", 3)
+	if len(parts) < 3 || strings.TrimSpace(parts[0]) != "" {
+		return ""
+	}
+	front := struct {
+		Title string `yaml:"title"`
+	}{}
+	if err := yaml.Unmarshal([]byte(parts[1]), &front); err != nil {
+		fmt.Printf("Warning: Could not read the front matter of %s: %v\n", fn, err)
+	}
+	return front.Title
+}
+
+func isAbsoluteURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
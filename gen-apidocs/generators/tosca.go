@@ -17,18 +17,39 @@ limitations under the License.
 package generators
 
 import (
+	"flag"
+	"fmt"
 	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
 	"strings"
 )
 
+var ToscaMinConfidence = flag.String("tosca-min-confidence", "medium",
+	"Lowest confidence (low, medium or high, none disables it) of constraints mined from descriptions to add to TOSCA properties")
+
+// --tosca-min-confidence parsed by ParseFlags
+var toscaMinConfidence = api.ConfidenceMedium
+
 func BuildToscaTypesFromDefinitions(config *api.Config, tosca *ToscaTypes) {
 	definitions := config.Definitions
 	for _, kind := range config.IncludedObjects {
 		def := definitions.ByKind[kind][0]
+		if IsExcludedDefinition(def) {
+			fmt.Printf("Leaving %s %s out of TOSCA, excluded by --tosca-exclude\n", def.GroupVersion(), kind)
+			continue
+		}
 		AddDefinitionToDataTypes(def, tosca)
 		AddDefinitionToNodeTypes(def, tosca)
 		PopulateToscaTypesFromComplexFields(def.Fields, tosca)
 	}
+	AddCompositesToNodeTypes(config, tosca)
+
+	// target of the namespace requirement of namespaced kinds
+	if defs, ok := definitions.ByKind[NamespaceKind]; ok {
+		if _, ok := tosca.NodeTypes[GetNodeTypeName(NamespaceKind)]; !ok {
+			AddDefinitionToToscaTypes(defs[0], tosca)
+			PopulateToscaTypesFromComplexFields(defs[0].Fields, tosca)
+		}
+	}
 }
 
 func AddDefinitionToDataTypes(def *api.Definition, tosca *ToscaTypes) {
@@ -36,11 +57,13 @@ func AddDefinitionToDataTypes(def *api.Definition, tosca *ToscaTypes) {
 		tosca.DataTypes[GetDataTypeName(def.Name)] = DataType{
 			DerivedFrom: GetToscaTypeFromSpec(def.Type),
 			Description: GetDescription(def.RawDescription),
+			Metadata: GetToscaTypeMetadata(def),
 		}
 	} else {
 		tosca.DataTypes[GetDataTypeName(def.Name)] = DataType{
 			DerivedFrom: DataTypeBase,
 			Description: GetDescription(def.RawDescription),
+			Metadata: GetToscaTypeMetadata(def),
 			Properties: GetDataTypeProperties(def.Fields),
 		}
 	}
@@ -48,9 +71,10 @@ func AddDefinitionToDataTypes(def *api.Definition, tosca *ToscaTypes) {
 
 func AddDefinitionToNodeTypes(def *api.Definition, tosca *ToscaTypes) {
 	if !def.IsWrapper() { // do not include wrappers
-		tosca.NodeTypes[GetNodeTypeName(def.Name)] = NodeType{
+		node_type := NodeType{
 			DerivedFrom: NodeTypeBase,
 			Description: GetDescription(def.RawDescription),
+			Metadata: GetToscaTypeMetadata(def),
 			Properties: GetNodeTypeProperties(GetDataTypeName(def.Name)),
 			Requirements: []map[string]RequirementDefinition{
 				{
@@ -115,9 +139,85 @@ func AddDefinitionToNodeTypes(def *api.Definition, tosca *ToscaTypes) {
 				},
 			},
 		}
+		AddImageArtifactToNodeType(def, &node_type, tosca)
+		AddReadOnlyFieldsToNodeType(def, &node_type, tosca)
+		if def.Namespaced {
+			node_type.Requirements = append(node_type.Requirements, map[string]RequirementDefinition{
+				NamespaceRequirement: RequirementDefinition{
+					Capability:   NamespaceReqCapability,
+					Node:         GetNodeTypeName(NamespaceKind),
+					Relationship: NamespaceReqRelationship,
+					Occurrences:  []int{0, 1},
+				},
+			})
+		}
+		tosca.NodeTypes[GetNodeTypeName(def.Name)] = node_type
 	}
 }
 
+// AddImageArtifactToNodeType declares a container image artifact on workload node types
+// (kinds embedding a PodSpec) and passes it to the create operation with the containers path.
+func AddImageArtifactToNodeType(def *api.Definition, node_type *NodeType, tosca *ToscaTypes) {
+	containers_path, ok := GetContainersPath(def)
+	if !ok {
+		return
+	}
+	tosca.ArtifactTypes[ImageArtifactType] = ArtifactType{
+		DerivedFrom: ImageArtifactTypeBase,
+		Description: "Docker container image used by the containers of a Kubernetes workload",
+	}
+	node_type.Artifacts = map[string]ArtifactDefinition{
+		ImageArtifact: ArtifactDefinition{
+			Type:        ImageArtifactType,
+			Description: "Image substituted into the containers found in " + containers_path,
+		},
+	}
+	create := node_type.Interfaces["Standard"].Operations["create"]
+	create.Inputs[ImageArtifact] = PropertyDefinition{
+		Type: "string",
+		Default: Assignment{
+			ToscaFunction: map[string][]string{
+				"get_artifact": []string{"SELF", ImageArtifact},
+			},
+		},
+	}
+	create.Inputs[ContainersPathInput] = PropertyDefinition{
+		Type:  "string",
+		Value: containers_path,
+	}
+}
+
+// GetContainersPath returns the dotted path from def to the containers of the
+// embedded PodSpec, e.g. spec.template.spec.containers for a Deployment.
+func GetContainersPath(def *api.Definition) (string, bool) {
+	type step struct {
+		def  *api.Definition
+		path string
+	}
+	visited := map[*api.Definition]bool{def: true}
+	queue := []step{{def: def}}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if s.def.Name == SpecPodSpec && s.path != "" {
+			return s.path + ".containers", true
+		}
+		for _, field := range s.def.Fields {
+			// paths through lists cannot be addressed by a single dotted path
+			if !field.HasComplexType() || IsArray(field.Type) || visited[field.Definition] {
+				continue
+			}
+			visited[field.Definition] = true
+			path := field.Name
+			if s.path != "" {
+				path = s.path + "." + field.Name
+			}
+			queue = append(queue, step{def: field.Definition, path: path})
+		}
+	}
+	return "", false
+}
+
 func AddDefinitionToToscaTypes(def *api.Definition, tosca *ToscaTypes) {
 	AddDefinitionToDataTypes(def, tosca)
 	AddDefinitionToNodeTypes(def, tosca)
@@ -128,6 +228,9 @@ func PopulateToscaTypesFromComplexFields(fields api.Fields, tosca *ToscaTypes) {
 		if !field.HasComplexType() || TypeExistsInTosca(GetDataTypeName(field.Name), tosca) {
 			continue
 		}
+		if !IsToscaProperty(field) {
+			continue
+		}
 		AddDefinitionToDataTypes(field.Definition, tosca)
 		PopulateToscaTypesFromComplexFields(field.Definition.Fields, tosca)
 	}
@@ -141,37 +244,65 @@ func TypeExistsInTosca(dt_name string, tosca *ToscaTypes) bool {
 func GetDataTypeProperties(fields api.Fields) map[string]PropertyDefinition {
 	properties := map[string]PropertyDefinition{}
 	for _, field := range fields {
-		var field_type string
-		var entry_schema EntrySchemaDefinition
-
-		if field.HasComplexType() {
-			base_type := GetBaseType(field.Type)
-			if field.Definition.IsWrapper() {
-				field_type = GetDataTypeName(base_type)
-			} else {
-				field_type = GetEntrySchema(field.Type)
-				entry_schema = EntrySchemaDefinition{
-					Type: GetDataTypeName(base_type),
-				}
-			}
+		if !IsToscaProperty(field) {
+			continue
+		}
+		properties[field.Name] = GetPropertyDefinition(field)
+	}
+	return properties
+}
+
+func GetPropertyDefinition(field *api.Field) PropertyDefinition {
+	var field_type string
+	var entry_schema EntrySchemaDefinition
+
+	if field.HasComplexType() {
+		base_type := GetBaseType(field.Type)
+		if field.Definition.IsWrapper() {
+			field_type = GetDataTypeName(base_type)
 		} else {
-			field_type = GetToscaTypeFromSpec(field.Type)
-			if IsArray(field.Type) {
-				field_type = ToscaArray
-				entry_schema = EntrySchemaDefinition{
-					Type: GetToscaTypeFromSpec(GetBaseType(field.Type)),
-				}
+			field_type = GetEntrySchema(field.Type)
+			entry_schema = EntrySchemaDefinition{
+				Type: GetDataTypeName(base_type),
 			}
 		}
-
-		properties[field.Name] = PropertyDefinition{
-			Type: field_type,
-			Description: GetDescription(field.Description),
-			Required: &field.Required,
-			EntrySchema: entry_schema,
+	} else {
+		field_type = GetToscaTypeFromSpec(field.Type)
+		if IsArray(field.Type) {
+			field_type = ToscaArray
+			entry_schema = EntrySchemaDefinition{
+				Type: GetToscaTypeFromSpec(GetBaseType(field.Type)),
+			}
 		}
 	}
-	return properties
+
+	property := PropertyDefinition{
+		Type: field_type,
+		Description: GetDescription(field.Description),
+		Required: &field.SchemaRequired,
+		Status: GetPropertyStatus(field),
+		EntrySchema: entry_schema,
+	}
+	AddConstraintsToPropertyDefinition(field.Constraints, &property)
+	return property
+}
+
+// AddConstraintsToPropertyDefinition adds the constraints mined from the field description
+// that were recognized with at least the confidence set by --tosca-min-confidence
+func AddConstraintsToPropertyDefinition(c api.FieldConstraints, property *PropertyDefinition) {
+	c = c.AtLeast(toscaMinConfidence)
+	if c.Default != nil {
+		property.Default = Assignment{Value: c.Default}
+	}
+	if len(c.AllowedValues) > 0 {
+		property.Constraints = append(property.Constraints, map[string]interface{}{"valid_values": c.AllowedValues})
+	}
+	if c.Minimum != nil {
+		property.Constraints = append(property.Constraints, map[string]interface{}{"greater_or_equal": *c.Minimum})
+	}
+	if c.Maximum != nil {
+		property.Constraints = append(property.Constraints, map[string]interface{}{"less_or_equal": *c.Maximum})
+	}
 }
 
 func GetNodeTypeProperties(dt_name string) map[string]PropertyDefinition {
@@ -203,14 +334,6 @@ func GetEntrySchema(t string) string {
 	return ToscaMap
 }
 
-func GetDescription(d string) string {
-	// n := 20
-	// if len(d) > n {
-	// 	return d[:n]
-	// }
-	return d
-}
-
 
 /* TYPES */
 
@@ -220,8 +343,11 @@ const ToscaMap = "map"
 const SpecArray = "array"
 const SpecArraySeparator = " " + SpecArray
 const SpecMap = "object"
+const SpecNumber = "number"
+const ToscaFloat = "float"
 const SpecIntOrString = "IntOrString"
 const SpecRawExtension = "RawExtension"
+const SpecPodSpec = "PodSpec"
 
 func GetToscaTypeFromSpec(spec_type string) string {
 	switch spec_type {
@@ -233,6 +359,8 @@ func GetToscaTypeFromSpec(spec_type string) string {
         return "string"
     case SpecRawExtension:
         return ToscaMap
+    case SpecNumber:
+        return ToscaFloat
     default:
         return spec_type
     }
@@ -247,6 +375,16 @@ const HostReqRelationship = "tosca.relationships.HostedOn"
 const DefinitionProperty = "definition"
 const InterfaceType = "tosca.interfaces.node.lifecycle.Standard"
 
+// artifact types
+const NamespaceKind = "Namespace"
+const NamespaceRequirement = "namespace"
+const NamespaceReqCapability = "tosca.capabilities.Node"
+const NamespaceReqRelationship = "tosca.relationships.DependsOn"
+const ImageArtifactType = "sodalite.artifacts.Kubernetes.Image.Docker"
+const ImageArtifactTypeBase = "tosca.artifacts.Deployment.Image"
+const ImageArtifact = "image"
+const ContainersPathInput = "containers_path"
+
 func GetDataTypeName(n string) string {
 	return string(DataTypeBase + "." + n)
 }
@@ -254,9 +392,18 @@ func GetDataTypeName(n string) string {
 type DataType struct {
 	DerivedFrom  string                             `yaml:"derived_from,omitempty"`
 	Description  string                             `yaml:"description,omitempty"`
+	Metadata     map[string]string                  `yaml:"metadata,omitempty"`
 	Properties   map[string]PropertyDefinition      `yaml:"properties,omitempty"`
 }
 
+// composite node types
+const CompositeNodeTypeBase = "tosca.nodes.Root"
+const CompositeNodeTypePrefix = "sodalite.nodes.Kubernetes.Composite"
+
+func GetCompositeNodeTypeName(n string) string {
+	return string(CompositeNodeTypePrefix + "." + n)
+}
+
 // node types
 func GetNodeTypeName(n string) string {
 	return string(NodeTypeBase + "." + n)
@@ -265,20 +412,53 @@ func GetNodeTypeName(n string) string {
 type NodeType struct {
 	DerivedFrom  string                             `yaml:"derived_from,omitempty"`
 	Description  string                             `yaml:"description,omitempty"`
+	Metadata     map[string]string                  `yaml:"metadata,omitempty"`
 	Properties   map[string]PropertyDefinition      `yaml:"properties,omitempty"`
 	Requirements []map[string]RequirementDefinition `yaml:"requirements,omitempty"`
 	Interfaces   map[string]InterfaceDefinition     `yaml:"interfaces,omitempty"`
+	Artifacts    map[string]ArtifactDefinition      `yaml:"artifacts,omitempty"`
+	Attributes   map[string]AttributeDefinition     `yaml:"attributes,omitempty"`
+}
+
+type AttributeDefinition struct {
+	Type        string                `yaml:"type"`
+	Description string                `yaml:"description,omitempty"`
+	EntrySchema EntrySchemaDefinition `yaml:"entry_schema,omitempty"`
+}
+
+type ArtifactType struct {
+	DerivedFrom string `yaml:"derived_from,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// Capability, relationship and interface types are not generated, they are read from imports
+// to check the references to them
+type EntityType struct {
+	DerivedFrom string `yaml:"derived_from,omitempty"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// File is required by TOSCA, node templates override it with the image reference
+type ArtifactDefinition struct {
+	Type        string `yaml:"type"`
+	File        string `yaml:"file"`
+	Description string `yaml:"description,omitempty"`
 }
 
 type EntrySchemaDefinition struct {
 	Type string `yaml:"type"`
 }
 
+// Required follows Default, yaml.v2 keeps the flow style of a scalar default
+// for the next mapping unless another field is written after it
 type PropertyDefinition struct {
 	Type        string             		`yaml:"type"`
 	Description string             		`yaml:"description,omitempty"`
-	Required    *bool               	`yaml:"required,omitempty"`
+	Value       string             		`yaml:"value,omitempty"`
 	Default     Assignment             	`yaml:"default,omitempty,flow"`
+	Required    *bool               	`yaml:"required,omitempty"`
+	Status      string             		`yaml:"status,omitempty"`
+	Constraints []map[string]interface{}	`yaml:"constraints,omitempty"`
 	EntrySchema EntrySchemaDefinition	`yaml:"entry_schema,omitempty"`
 }
 
@@ -286,6 +466,7 @@ type RequirementDefinition struct {
 	Capability   string `yaml:"capability"`
 	Node         string `yaml:"node,omitempty"`
 	Relationship string `yaml:"relationship"`
+	Occurrences  []int  `yaml:"occurrences,omitempty,flow"`
 }
 
 type InterfaceDefinition struct {
@@ -302,14 +483,88 @@ type ImplementationDefinition struct {
 	Primary string	`yaml:"primary,omitempty"`
 }
 
-// TODO: make it more elegant to accept values of various types
+// Assignment is either a plain value or a TOSCA function like get_property
 type Assignment struct {
-	StringValue   string 				`yaml:"value,omitempty"`
-	ToscaFunction map[string][]string 	`yaml:"value,inline,omitempty"`
+	Value         interface{}
+	ToscaFunction map[string][]string
+}
+
+func (a Assignment) MarshalYAML() (interface{}, error) {
+	if a.ToscaFunction != nil {
+		return a.ToscaFunction, nil
+	}
+	return a.Value, nil
+}
+
+func (a *Assignment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&a.ToscaFunction); err == nil && len(a.ToscaFunction) == 1 {
+		for name := range a.ToscaFunction {
+			if strings.HasPrefix(name, "get_") {
+				return nil
+			}
+		}
+	}
+	a.ToscaFunction = nil
+	return unmarshal(&a.Value)
+}
+
+type ServiceTemplate struct {
+	Version          string           `yaml:"tosca_definitions_version"`
+	Description      string           `yaml:"description,omitempty"`
+	Imports          []string         `yaml:"imports,omitempty"`
+	TopologyTemplate TopologyTemplate `yaml:"topology_template"`
+}
+
+type TopologyTemplate struct {
+	Inputs               map[string]PropertyDefinition `yaml:"inputs,omitempty"`
+	SubstitutionMappings *SubstitutionMappings         `yaml:"substitution_mappings,omitempty"`
+	NodeTemplates        map[string]NodeTemplate       `yaml:"node_templates,omitempty"`
+}
+
+// Properties map to the input of the same name, requirements to [ node_template, requirement ]
+type SubstitutionMappings struct {
+	NodeType     string              `yaml:"node_type"`
+	Properties   map[string][]string `yaml:"properties,omitempty"`
+	Requirements map[string][]string `yaml:"requirements,omitempty"`
+}
+
+type NodeTemplate struct {
+	Type         string                 `yaml:"type"`
+	Description  string                 `yaml:"description,omitempty"`
+	Properties   map[string]interface{} `yaml:"properties,omitempty"`
+	Requirements []map[string]RequirementAssignment `yaml:"requirements,omitempty"`
+}
+
+// RequirementAssignment is written in the short form, i.e. just the node template name,
+// unless a relationship is given
+type RequirementAssignment struct {
+	Node         string `yaml:"node,omitempty"`
+	Relationship string `yaml:"relationship,omitempty"`
+}
+
+func (r RequirementAssignment) MarshalYAML() (interface{}, error) {
+	if r.Relationship == "" {
+		return r.Node, nil
+	}
+	type plain RequirementAssignment
+	return plain(r), nil
+}
+
+func (r *RequirementAssignment) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&r.Node); err == nil {
+		return nil
+	}
+	type plain RequirementAssignment
+	return unmarshal((*plain)(r))
 }
 
 type ToscaTypes struct {
 	Version   	string 				`yaml:"tosca_definitions_version,omitempty"`
+	Imports		[]string			`yaml:"imports,omitempty"`
+	ArtifactTypes	map[string]ArtifactType	`yaml:"artifact_types,omitempty"`
 	DataTypes 	map[string]DataType `yaml:"data_types,omitempty"`
 	NodeTypes 	map[string]NodeType `yaml:"node_types,omitempty"` 
+	CapabilityTypes		map[string]EntityType	`yaml:"capability_types,omitempty"`
+	RelationshipTypes	map[string]EntityType	`yaml:"relationship_types,omitempty"`
+	InterfaceTypes		map[string]EntityType	`yaml:"interface_types,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ToscaReadOnlyFields = flag.String("tosca-readonly-fields", ReadOnlyFieldsAttributes,
+	"What to do with read-only and server-populated fields in TOSCA: attributes moves them to node type attributes, drop leaves them out, keep keeps them as properties.")
+
+const ReadOnlyFieldsAttributes = "attributes"
+const ReadOnlyFieldsDrop = "drop"
+const ReadOnlyFieldsKeep = "keep"
+
+// IsToscaProperty is false for fields excluded by --tosca-exclude and fields users cannot set,
+// unless they are kept as properties
+func IsToscaProperty(field *api.Field) bool {
+	if IsExcludedField(field) {
+		return false
+	}
+	switch *ToscaReadOnlyFields {
+	case ReadOnlyFieldsKeep:
+		return true
+	case ReadOnlyFieldsAttributes, ReadOnlyFieldsDrop:
+		return field.Access.IsSettable()
+	}
+	panic(fmt.Sprintf("Unknown --tosca-readonly-fields %s, expected %s, %s or %s", *ToscaReadOnlyFields,
+		ReadOnlyFieldsAttributes, ReadOnlyFieldsDrop, ReadOnlyFieldsKeep))
+}
+
+// AddReadOnlyFieldsToNodeType adds the fields of def users cannot set as node type attributes
+// named after their path, e.g. metadata.uid. Fields inside lists and templates are left out.
+func AddReadOnlyFieldsToNodeType(def *api.Definition, node_type *NodeType, tosca *ToscaTypes) {
+	if *ToscaReadOnlyFields != ReadOnlyFieldsAttributes {
+		return
+	}
+	attributes := map[string]AttributeDefinition{}
+	addReadOnlyFields(def, "", attributes, tosca, map[string]bool{})
+	if len(attributes) > 0 {
+		node_type.Attributes = attributes
+	}
+}
+
+func addReadOnlyFields(def *api.Definition, prefix string, attributes map[string]AttributeDefinition, tosca *ToscaTypes, parents map[string]bool) {
+	parents[def.Name] = true
+	defer delete(parents, def.Name)
+
+	for _, field := range def.Fields {
+		if IsExcludedField(field) {
+			continue
+		}
+		if !field.Access.IsSettable() {
+			property := GetPropertyDefinition(field)
+			attributes[prefix+field.Name] = AttributeDefinition{
+				Type:        property.Type,
+				Description: property.Description,
+				EntrySchema: property.EntrySchema,
+			}
+			if field.HasComplexType() {
+				AddDefinitionToDataTypes(field.Definition, tosca)
+				PopulateToscaTypesFromComplexFields(field.Definition.Fields, tosca)
+			}
+			continue
+		}
+		// the system only fills the metadata of the object, not of embedded templates
+		if field.HasComplexType() && field.Definition.Name == "ObjectMeta" && prefix != "" {
+			continue
+		}
+		if field.HasComplexType() && !field.Definition.IsWrapper() && !IsArray(field.Type) && !parents[field.Definition.Name] {
+			addReadOnlyFields(field.Definition, prefix+field.Name+".", attributes, tosca, parents)
+		}
+	}
+}
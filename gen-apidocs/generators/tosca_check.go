@@ -0,0 +1,290 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ToscaImports = flag.String("tosca-imports", "",
+	"Comma separated TOSCA files imported by the generated definitions, relative to the TOSCA module directory.")
+
+// Normative types of the TOSCA Simple Profile in YAML 1.3 the generated definitions may refer to
+var ToscaNormativeTypes = map[string]bool{
+	"tosca.datatypes.Root":                     true,
+	"tosca.datatypes.json":                     true,
+	"tosca.datatypes.xml":                      true,
+	"tosca.datatypes.Credential":               true,
+	"tosca.datatypes.TimeInterval":             true,
+	"tosca.datatypes.network.NetworkInfo":      true,
+	"tosca.datatypes.network.PortInfo":         true,
+	"tosca.datatypes.network.PortDef":          true,
+	"tosca.datatypes.network.PortSpec":         true,
+	"tosca.artifacts.Root":                     true,
+	"tosca.artifacts.File":                     true,
+	"tosca.artifacts.Deployment":               true,
+	"tosca.artifacts.Deployment.Image":         true,
+	"tosca.artifacts.Deployment.Image.VM":      true,
+	"tosca.artifacts.Implementation":           true,
+	"tosca.artifacts.Implementation.Bash":      true,
+	"tosca.artifacts.Implementation.Python":    true,
+	"tosca.artifacts.template":                 true,
+	"tosca.capabilities.Root":                  true,
+	"tosca.capabilities.Node":                  true,
+	"tosca.capabilities.Compute":               true,
+	"tosca.capabilities.Network":               true,
+	"tosca.capabilities.Storage":               true,
+	"tosca.capabilities.Container":             true,
+	"tosca.capabilities.Endpoint":              true,
+	"tosca.capabilities.Endpoint.Public":       true,
+	"tosca.capabilities.Endpoint.Admin":        true,
+	"tosca.capabilities.Endpoint.Database":     true,
+	"tosca.capabilities.Attachment":            true,
+	"tosca.capabilities.OperatingSystem":       true,
+	"tosca.capabilities.Scalable":              true,
+	"tosca.capabilities.network.Bindable":      true,
+	"tosca.capabilities.network.Linkable":      true,
+	"tosca.relationships.Root":                 true,
+	"tosca.relationships.DependsOn":            true,
+	"tosca.relationships.HostedOn":             true,
+	"tosca.relationships.ConnectsTo":           true,
+	"tosca.relationships.AttachesTo":           true,
+	"tosca.relationships.RoutesTo":             true,
+	"tosca.relationships.network.LinksTo":      true,
+	"tosca.relationships.network.BindsTo":      true,
+	"tosca.interfaces.Root":                    true,
+	"tosca.interfaces.node.lifecycle.Standard": true,
+	"tosca.interfaces.relationship.Configure":  true,
+	"tosca.nodes.Root":                         true,
+	"tosca.nodes.Abstract.Compute":             true,
+	"tosca.nodes.Compute":                      true,
+	"tosca.nodes.SoftwareComponent":            true,
+	"tosca.nodes.WebServer":                    true,
+	"tosca.nodes.WebApplication":               true,
+	"tosca.nodes.DBMS":                         true,
+	"tosca.nodes.Database":                     true,
+	"tosca.nodes.Abstract.Storage":             true,
+	"tosca.nodes.Storage.ObjectStorage":        true,
+	"tosca.nodes.Storage.BlockStorage":         true,
+	"tosca.nodes.Container.Runtime":            true,
+	"tosca.nodes.Container.Application":        true,
+	"tosca.nodes.LoadBalancer":                 true,
+	"tosca.nodes.network.Network":              true,
+	"tosca.nodes.network.Port":                 true,
+}
+
+// CheckGeneratedToscaTypes checks the generated definitions before they are written and exits
+// on dangling references unless --allow-errors is set.
+func CheckGeneratedToscaTypes(tosca *ToscaTypes) {
+	imported := NewToscaTypes()
+	for _, i := range tosca.Imports {
+		path := filepath.Join(ToscaModuleDir, i)
+		if _, err := os.Stat(path); err != nil {
+			fmt.Printf("Warning: Could not load import %s, its types are not checked: %v\n", i, err)
+			continue
+		}
+		loadToscaFile(path, imported)
+	}
+
+	errors, warnings := CheckToscaTypes(tosca, imported)
+	for _, w := range warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+	if len(errors) > 0 {
+		fmt.Fprintf(os.Stderr, "Dangling references in %s:\n", ToscaDefinitionsFile)
+		for _, e := range errors {
+			fmt.Fprintf(os.Stderr, "%s\n", e)
+		}
+		if !*api.AllowErrors {
+			fmt.Fprintf(os.Stderr, "Dangling references found in generated TOSCA definitions, %s was not written.\n",
+				ToscaDefinitionsFile)
+			os.Exit(1)
+		}
+	}
+}
+
+// Categories of TOSCA types, a reference must name a type of the category it expects.
+// Normative and SODALITE type names contain their category, e.g. tosca.capabilities.Node.
+const (
+	ToscaDataTypes         = "datatypes"
+	ToscaNodeTypes         = "nodes"
+	ToscaCapabilityTypes   = "capabilities"
+	ToscaRelationshipTypes = "relationships"
+	ToscaInterfaceTypes    = "interfaces"
+	ToscaArtifactTypes     = "artifacts"
+)
+
+var toscaCategoryNames = map[string]string{
+	ToscaDataTypes:         "data",
+	ToscaNodeTypes:         "node",
+	ToscaCapabilityTypes:   "capability",
+	ToscaRelationshipTypes: "relationship",
+	ToscaInterfaceTypes:    "interface",
+	ToscaArtifactTypes:     "artifact",
+}
+
+// CheckToscaTypes returns the references in tosca to types of the expected category defined neither in tosca,
+// imported, the normative set nor the SODALITE library as errors, and empty and unused data and artifact types as warnings.
+func CheckToscaTypes(tosca, imported *ToscaTypes) ([]string, []string) {
+	c := &toscaChecker{tosca: tosca, imported: imported, used: map[string]bool{}}
+
+	for name, nt := range tosca.NodeTypes {
+		path := "node_types." + name
+		c.checkType(path+".derived_from", nt.DerivedFrom, ToscaNodeTypes)
+		c.checkProperties(path+".properties", nt.Properties)
+		for _, requirements := range nt.Requirements {
+			for r, def := range requirements {
+				rp := path + ".requirements." + r
+				c.checkType(rp+".capability", def.Capability, ToscaCapabilityTypes)
+				c.checkType(rp+".node", def.Node, ToscaNodeTypes)
+				c.checkType(rp+".relationship", def.Relationship, ToscaRelationshipTypes)
+			}
+		}
+		for i, def := range nt.Interfaces {
+			ip := path + ".interfaces." + i
+			c.checkType(ip+".type", def.Type, ToscaInterfaceTypes)
+			for o, op := range def.Operations {
+				c.checkProperties(ip+".operations."+o+".inputs", op.Inputs)
+			}
+		}
+		for a, def := range nt.Artifacts {
+			c.checkType(path+".artifacts."+a+".type", def.Type, ToscaArtifactTypes)
+		}
+		for a, def := range nt.Attributes {
+			c.checkType(path+".attributes."+a+".type", def.Type, ToscaDataTypes)
+			if def.EntrySchema.Type != "" {
+				c.checkType(path+".attributes."+a+".entry_schema.type", def.EntrySchema.Type, ToscaDataTypes)
+			}
+		}
+	}
+	for name, dt := range tosca.DataTypes {
+		path := "data_types." + name
+		c.checkType(path+".derived_from", dt.DerivedFrom, ToscaDataTypes)
+		c.checkProperties(path+".properties", dt.Properties)
+		if dt.DerivedFrom == DataTypeBase && len(dt.Properties) == 0 {
+			c.warnings = append(c.warnings, path+": empty data type")
+		}
+	}
+	for name, at := range tosca.ArtifactTypes {
+		c.checkType("artifact_types."+name+".derived_from", at.DerivedFrom, ToscaArtifactTypes)
+	}
+	for name, t := range tosca.CapabilityTypes {
+		c.checkType("capability_types."+name+".derived_from", t.DerivedFrom, ToscaCapabilityTypes)
+	}
+	for name, t := range tosca.RelationshipTypes {
+		c.checkType("relationship_types."+name+".derived_from", t.DerivedFrom, ToscaRelationshipTypes)
+	}
+	for name, t := range tosca.InterfaceTypes {
+		c.checkType("interface_types."+name+".derived_from", t.DerivedFrom, ToscaInterfaceTypes)
+	}
+
+	for name := range tosca.DataTypes {
+		if !c.used[name] {
+			c.warnings = append(c.warnings, "data_types."+name+": unused type")
+		}
+	}
+	for name := range tosca.ArtifactTypes {
+		if !c.used[name] {
+			c.warnings = append(c.warnings, "artifact_types."+name+": unused type")
+		}
+	}
+
+	sort.Strings(c.errors)
+	sort.Strings(c.warnings)
+	return c.errors, c.warnings
+}
+
+type toscaChecker struct {
+	tosca    *ToscaTypes
+	imported *ToscaTypes
+	used     map[string]bool
+	errors   []string
+	warnings []string
+}
+
+func (c *toscaChecker) checkProperties(path string, properties map[string]PropertyDefinition) {
+	for name, p := range properties {
+		c.checkType(path+"."+name+".type", p.Type, ToscaDataTypes)
+		if p.EntrySchema.Type != "" {
+			c.checkType(path+"."+name+".entry_schema.type", p.EntrySchema.Type, ToscaDataTypes)
+		}
+	}
+}
+
+// checkType records the use of t and reports it if it is not defined as a type of the category
+func (c *toscaChecker) checkType(path, t, category string) {
+	if t == "" {
+		return
+	}
+	c.used[t] = true
+	if c.isDefined(t, category) {
+		return
+	}
+	c.errors = append(c.errors, fmt.Sprintf("%s: undefined %s type %s", path, toscaCategoryNames[category], t))
+}
+
+func (c *toscaChecker) isDefined(t, category string) bool {
+	if category == ToscaDataTypes && ToscaPrimitiveTypes[t] {
+		return true
+	}
+	if (ToscaNormativeTypes[t] || ExternalToscaTypes[t]) && strings.Contains(t, "."+category+".") {
+		return true
+	}
+	for _, types := range []*ToscaTypes{c.tosca, c.imported} {
+		if hasToscaType(types, t, category) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasToscaType(types *ToscaTypes, t, category string) bool {
+	ok := false
+	switch category {
+	case ToscaDataTypes:
+		_, ok = types.DataTypes[t]
+	case ToscaNodeTypes:
+		_, ok = types.NodeTypes[t]
+	case ToscaCapabilityTypes:
+		_, ok = types.CapabilityTypes[t]
+	case ToscaRelationshipTypes:
+		_, ok = types.RelationshipTypes[t]
+	case ToscaInterfaceTypes:
+		_, ok = types.InterfaceTypes[t]
+	case ToscaArtifactTypes:
+		_, ok = types.ArtifactTypes[t]
+	}
+	return ok
+}
+
+// GetToscaImports returns the files listed in --tosca-imports
+func GetToscaImports() []string {
+	imports := []string{}
+	for _, i := range strings.Split(*ToscaImports, ",") {
+		if i = strings.TrimSpace(i); i != "" {
+			imports = append(imports, i)
+		}
+	}
+	return imports
+}
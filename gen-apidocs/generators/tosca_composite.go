@@ -0,0 +1,182 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+// AddCompositesToNodeTypes adds an abstract node type for every composite object
+// and makes sure the node types of its members are generated.
+func AddCompositesToNodeTypes(config *api.Config, tosca *ToscaTypes) {
+	for _, c := range config.CompositeObjects {
+		node_type := NodeType{
+			DerivedFrom: CompositeNodeTypeBase,
+			Description: GetCompositeDescription(c),
+			Properties:  map[string]PropertyDefinition{},
+		}
+		for _, m := range c.Members {
+			def := GetCompositeMemberDefinition(config, c, m)
+			if _, ok := tosca.NodeTypes[GetNodeTypeName(def.Name)]; !ok {
+				AddDefinitionToToscaTypes(def, tosca)
+				PopulateToscaTypesFromComplexFields(def.Fields, tosca)
+			}
+			node_type.Requirements = append(node_type.Requirements, map[string]RequirementDefinition{
+				GetCompositeHostRequirement(m): RequirementDefinition{
+					Capability:   HostReqCapability,
+					Node:         HostReqNode,
+					Relationship: HostReqRelationship,
+				},
+			})
+		}
+		for _, p := range c.Properties {
+			property := GetCompositePropertyDefinition(config, c, p)
+			if existing, ok := node_type.Properties[p.Name]; ok && existing.Type != property.Type {
+				panic(fmt.Sprintf("Composite %s exposes property %s with types %s and %s",
+					c.Name, p.Name, existing.Type, property.Type))
+			}
+			node_type.Properties[p.Name] = property
+		}
+		tosca.NodeTypes[GetCompositeNodeTypeName(c.Name)] = node_type
+	}
+}
+
+// BuildCompositeTemplates returns the substitution templates of the composite objects
+// by the name of the file they should be written to.
+func BuildCompositeTemplates(config *api.Config) map[string]*ServiceTemplate {
+	templates := map[string]*ServiceTemplate{}
+	for _, c := range config.CompositeObjects {
+		topology := TopologyTemplate{
+			Inputs: map[string]PropertyDefinition{},
+			SubstitutionMappings: &SubstitutionMappings{
+				NodeType:     GetCompositeNodeTypeName(c.Name),
+				Properties:   map[string][]string{},
+				Requirements: map[string][]string{},
+			},
+			NodeTemplates: map[string]NodeTemplate{},
+		}
+
+		definitions := map[string]map[string]interface{}{}
+		for _, m := range c.Members {
+			def := GetCompositeMemberDefinition(config, c, m)
+			definitions[m.GetName()] = map[string]interface{}{
+				"apiVersion": def.GroupVersion(),
+				"kind":       def.Name,
+			}
+			topology.NodeTemplates[m.GetName()] = NodeTemplate{
+				Type: GetNodeTypeName(def.Name),
+				Properties: map[string]interface{}{
+					DefinitionProperty: definitions[m.GetName()],
+				},
+			}
+			topology.SubstitutionMappings.Requirements[GetCompositeHostRequirement(m)] = []string{m.GetName(), "host"}
+		}
+
+		for _, p := range c.Properties {
+			topology.Inputs[p.Name] = GetCompositePropertyDefinition(config, c, p)
+			topology.SubstitutionMappings.Properties[p.Name] = []string{p.Name}
+			SetValueByPath(definitions[p.Member], p.Path, map[string]string{"get_input": p.Name})
+		}
+
+		templates[strings.ToLower(c.Name)+"_composite.yaml"] = &ServiceTemplate{
+			Version:          "tosca_simple_yaml_1_3",
+			Description:      GetCompositeDescription(c),
+			Imports:          []string{ToscaDefinitionsFile},
+			TopologyTemplate: topology,
+		}
+	}
+	return templates
+}
+
+func GetCompositeDescription(c api.CompositeObject) string {
+	if len(c.Description) > 0 {
+		return c.Description
+	}
+	kinds := []string{}
+	for _, m := range c.Members {
+		kinds = append(kinds, m.Kind)
+	}
+	return fmt.Sprintf("%s composed of %s", c.Name, strings.Join(kinds, ", "))
+}
+
+func GetCompositeHostRequirement(m api.CompositeMember) string {
+	return m.GetName() + "_host"
+}
+
+func GetCompositeMemberDefinition(config *api.Config, c api.CompositeObject, m api.CompositeMember) *api.Definition {
+	defs, ok := config.Definitions.ByKind[m.Kind]
+	if !ok || len(defs) == 0 {
+		panic(fmt.Sprintf("Could not find definition for member %s of composite %s", m.Kind, c.Name))
+	}
+	if IsExcludedDefinition(defs[0]) {
+		panic(fmt.Sprintf("Member %s of composite %s is excluded by --tosca-exclude", m.Kind, c.Name))
+	}
+	return defs[0]
+}
+
+func GetCompositePropertyDefinition(config *api.Config, c api.CompositeObject, p api.CompositeProperty) PropertyDefinition {
+	for _, m := range c.Members {
+		if m.GetName() != p.Member {
+			continue
+		}
+		def := GetCompositeMemberDefinition(config, c, m)
+		field, ok := def.GetFieldByPath(p.Path)
+		if !ok {
+			panic(fmt.Sprintf("Could not find field %s in %s for property %s of composite %s",
+				p.Path, def.Name, p.Name, c.Name))
+		}
+		if IsExcludedField(field) {
+			panic(fmt.Sprintf("Property %s of composite %s sets field %s excluded by --tosca-exclude",
+				p.Name, c.Name, p.Path))
+		}
+		if !IsToscaProperty(field) {
+			panic(fmt.Sprintf("Property %s of composite %s cannot set %s field %s",
+				p.Name, c.Name, field.Access, p.Path))
+		}
+		keys := strings.Split(p.Path, ".")
+		for i := 1; i < len(keys); i++ {
+			parent_path := strings.Join(keys[:i], ".")
+			if parent, _ := def.GetFieldByPath(parent_path); IsArray(parent.Type) {
+				panic(fmt.Sprintf("Property %s of composite %s cannot be set inside list %s",
+					p.Name, c.Name, parent_path))
+			}
+		}
+		property := GetPropertyDefinition(field)
+		if len(p.Description) > 0 {
+			property.Description = p.Description
+		}
+		return property
+	}
+	panic(fmt.Sprintf("Unknown member %s for property %s of composite %s", p.Member, p.Name, c.Name))
+}
+
+// SetValueByPath sets the value at the dotted path in m, creating the intermediate maps
+func SetValueByPath(m map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
+}
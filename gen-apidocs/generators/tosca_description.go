@@ -0,0 +1,122 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ToscaDescriptions = flag.String("tosca-descriptions", DescriptionsFull,
+	"Descriptions of generated TOSCA types and properties: full, first-sentence, truncate (to --tosca-description-length) or none.")
+var ToscaDescriptionLength = flag.Int("tosca-description-length", 200,
+	"Maximum length of TOSCA descriptions with --tosca-descriptions=truncate.")
+var ToscaStripMarkdown = flag.Bool("tosca-strip-markdown", false,
+	"Remove markdown links, code and emphasis from TOSCA descriptions.")
+
+const DescriptionsFull = "full"
+const DescriptionsFirstSentence = "first-sentence"
+const DescriptionsTruncate = "truncate"
+const DescriptionsNone = "none"
+
+var (
+	markdownLinkPattern     = regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
+	markdownEmphasisPattern = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	sentenceEndPattern      = regexp.MustCompile(`[.!?]\s`)
+)
+
+// Abbreviations a period does not end a sentence after
+var descriptionAbbreviations = []string{"e.g", "i.e", "vs", "etc"}
+
+// GetDescription applies the description policy set by --tosca-descriptions and --tosca-strip-markdown
+func GetDescription(d string) string {
+	d = api.UnescapeAsterisks(d)
+	if *ToscaStripMarkdown {
+		d = StripMarkdown(d)
+	}
+
+	switch *ToscaDescriptions {
+	case DescriptionsFull:
+		return d
+	case DescriptionsFirstSentence:
+		return GetFirstSentence(d)
+	case DescriptionsTruncate:
+		return TruncateDescription(d, *ToscaDescriptionLength)
+	}
+	return ""
+}
+
+// checkDescriptionFlags exits on an unknown --tosca-descriptions policy or a truncate
+// --tosca-description-length too short for the ellipsis and at least one character
+func checkDescriptionFlags() {
+	switch *ToscaDescriptions {
+	case DescriptionsFull, DescriptionsFirstSentence, DescriptionsTruncate, DescriptionsNone:
+	default:
+		fmt.Printf("Unknown --tosca-descriptions %s, expected %s, %s, %s or %s\n", *ToscaDescriptions,
+			DescriptionsFull, DescriptionsFirstSentence, DescriptionsTruncate, DescriptionsNone)
+		os.Exit(1)
+	}
+	if *ToscaDescriptions == DescriptionsTruncate && *ToscaDescriptionLength < 4 {
+		fmt.Printf("Invalid --tosca-description-length %d, expected at least 4\n", *ToscaDescriptionLength)
+		os.Exit(1)
+	}
+}
+
+// StripMarkdown replaces links by their text and removes code quotes and emphasis
+func StripMarkdown(d string) string {
+	d = markdownLinkPattern.ReplaceAllString(d, "$1")
+	d = markdownEmphasisPattern.ReplaceAllString(d, "$1$2")
+	return strings.Replace(d, "`", "", -1)
+}
+
+// GetFirstSentence returns the description up to the first period followed by a space
+// which does not end an abbreviation, with whitespace collapsed
+func GetFirstSentence(d string) string {
+	d = strings.Join(strings.Fields(d), " ")
+	for _, loc := range sentenceEndPattern.FindAllStringIndex(d, -1) {
+		abbreviation := false
+		for _, a := range descriptionAbbreviations {
+			if strings.HasSuffix(d[:loc[0]], a) {
+				abbreviation = true
+			}
+		}
+		if !abbreviation {
+			return d[:loc[0]+1]
+		}
+	}
+	return d
+}
+
+// TruncateDescription shortens a description longer than length at a word boundary
+// and ends it with an ellipsis, with whitespace collapsed
+func TruncateDescription(d string, length int) string {
+	d = strings.Join(strings.Fields(d), " ")
+	runes := []rune(d)
+	if len(runes) <= length {
+		return d
+	}
+	cut := string(runes[:length-3])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,;:") + "..."
+}
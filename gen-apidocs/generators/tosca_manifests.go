@@ -0,0 +1,217 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ManifestsTemplateFile = flag.String("manifests-template", "kubernetes_manifests.yaml",
+	"File name of the service template converted from manifests, written to the TOSCA module directory.")
+
+// Node template and input of the cluster hosting the converted objects
+const ClusterNodeTemplate = "kubernetes_cluster"
+const KubeconfigInput = "kubeconfig"
+
+// ConvertManifests converts the Kubernetes objects in the given manifest files
+// and directories into a service template using the generated node types.
+func ConvertManifests(paths []string) {
+	if len(paths) == 0 {
+		fmt.Printf("No manifest files or directories given.\n")
+		os.Exit(1)
+	}
+
+	config := api.NewConfig()
+	tosca := NewToscaTypes()
+	BuildToscaTypesFromDefinitions(config, tosca)
+
+	objects := []map[interface{}]interface{}{}
+	for _, path := range paths {
+		objects = append(objects, LoadManifests(path)...)
+	}
+
+	writeToscaFile(*ManifestsTemplateFile, BuildManifestsTemplate(config, tosca, objects))
+}
+
+// LoadManifests reads the objects of all YAML documents in path, walking it if it is a directory.
+// Items of List objects are returned as separate objects.
+func LoadManifests(path string) []map[interface{}]interface{} {
+	objects := []map[interface{}]interface{}{}
+	err := filepath.Walk(path, func(fn string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(fn)
+		if info.IsDir() || (fn != path && ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		objects = append(objects, loadManifestFile(fn)...)
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("Could not read manifests from %s: %v", path, err))
+	}
+	return objects
+}
+
+func loadManifestFile(fn string) []map[interface{}]interface{} {
+	f, err := os.Open(fn)
+	if err != nil {
+		panic(err)
+	}
+	defer f.Close()
+
+	objects := []map[interface{}]interface{}{}
+	decoder := yaml.NewDecoder(f)
+	for {
+		obj := map[interface{}]interface{}{}
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(fmt.Sprintf("Could not parse manifest %s: %v", fn, err))
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		if items, ok := obj["items"].([]interface{}); ok && GetManifestString(obj, "kind") == "List" {
+			for _, item := range items {
+				if o, ok := item.(map[interface{}]interface{}); ok {
+					objects = append(objects, o)
+				}
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// BuildManifestsTemplate returns a service template with a node template for every object,
+// hosted on a cluster node template and depending on the node template of its namespace.
+func BuildManifestsTemplate(config *api.Config, tosca *ToscaTypes, objects []map[interface{}]interface{}) *ServiceTemplate {
+	topology := TopologyTemplate{
+		Inputs: map[string]PropertyDefinition{
+			KubeconfigInput: PropertyDefinition{
+				Type:        "string",
+				Description: "Kubeconfig of the cluster the objects are deployed to",
+			},
+		},
+		NodeTemplates: map[string]NodeTemplate{
+			ClusterNodeTemplate: NodeTemplate{
+				Type: HostReqNode,
+				Properties: map[string]interface{}{
+					KubeconfigInput: map[string]string{"get_input": KubeconfigInput},
+				},
+			},
+		},
+	}
+
+	names := make([]string, len(objects))
+	namespaces := map[string]string{}
+	for i, obj := range objects {
+		kind := GetManifestString(obj, "kind")
+		name := GetManifestString(GetManifestMetadata(obj), "name")
+		if kind == "" || name == "" {
+			reportToscaError(fmt.Sprintf("Found object without kind or name: %v", obj))
+			continue
+		}
+		if _, ok := tosca.NodeTypes[GetNodeTypeName(kind)]; !ok {
+			reportToscaError(fmt.Sprintf("No node type generated for %s %s, add it to included_objects", kind, name))
+			continue
+		}
+		if version := GetManifestString(obj, "apiVersion"); version != config.Definitions.ByKind[kind][0].GroupVersion() {
+			fmt.Fprintf(os.Stderr, "Warning: %s %s uses %s, node type was generated from %s\n",
+				kind, name, version, config.Definitions.ByKind[kind][0].GroupVersion())
+		}
+
+		names[i] = GetManifestNodeTemplateName(obj, topology.NodeTemplates)
+		topology.NodeTemplates[names[i]] = NodeTemplate{}
+		if kind == NamespaceKind {
+			namespaces[name] = names[i]
+		}
+	}
+
+	for i, obj := range objects {
+		if names[i] == "" {
+			continue
+		}
+		kind := GetManifestString(obj, "kind")
+		requirements := []map[string]RequirementAssignment{{"host": {Node: ClusterNodeTemplate}}}
+		namespace := GetManifestString(GetManifestMetadata(obj), "namespace")
+		if n, ok := namespaces[namespace]; ok && config.Definitions.ByKind[kind][0].Namespaced {
+			requirements = append(requirements, map[string]RequirementAssignment{NamespaceRequirement: {Node: n}})
+		}
+		topology.NodeTemplates[names[i]] = NodeTemplate{
+			Type: GetNodeTypeName(kind),
+			Properties: map[string]interface{}{
+				DefinitionProperty: obj,
+			},
+			Requirements: requirements,
+		}
+	}
+
+	return &ServiceTemplate{
+		Version:          "tosca_simple_yaml_1_3",
+		Description:      "Kubernetes objects converted from manifests",
+		Imports:          []string{ToscaDefinitionsFile},
+		TopologyTemplate: topology,
+	}
+}
+
+// GetManifestNodeTemplateName names node templates <kind>_<name>, adding the namespace
+// if objects of the same kind and name exist in several namespaces.
+func GetManifestNodeTemplateName(obj map[interface{}]interface{}, templates map[string]NodeTemplate) string {
+	metadata := GetManifestMetadata(obj)
+	name := strings.ToLower(GetManifestString(obj, "kind")) + "_" + GetManifestString(metadata, "name")
+	if _, ok := templates[name]; !ok {
+		return name
+	}
+	namespaced := name + "_" + GetManifestString(metadata, "namespace")
+	if _, ok := templates[namespaced]; ok {
+		panic(fmt.Sprintf("Found duplicate object %s", namespaced))
+	}
+	return namespaced
+}
+
+func GetManifestMetadata(obj map[interface{}]interface{}) map[interface{}]interface{} {
+	metadata, _ := obj["metadata"].(map[interface{}]interface{})
+	return metadata
+}
+
+func GetManifestString(m map[interface{}]interface{}, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// reportToscaError fails on errors in manifests and service templates unless --allow-errors is set
+func reportToscaError(msg string) {
+	if !*api.AllowErrors {
+		panic(msg)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var ToscaExclude = flag.String("tosca-exclude", "",
+	"Comma separated maturity levels (alpha, beta, deprecated) of kinds and fields to leave out of TOSCA.")
+
+const ExcludeDeprecated = "deprecated"
+
+// TOSCA property status of pre-release and deprecated fields
+const PropertyStatusExperimental = "experimental"
+const PropertyStatusDeprecated = "deprecated"
+
+// Levels listed in --tosca-exclude, parsed by ParseFlags
+var toscaExcludes = map[string]bool{}
+
+// parseToscaExcludes reads the levels listed in --tosca-exclude and exits on unknown ones
+func parseToscaExcludes() {
+	for _, e := range strings.Split(*ToscaExclude, ",") {
+		switch e = strings.TrimSpace(e); e {
+		case "":
+		case string(api.MaturityAlpha), string(api.MaturityBeta), ExcludeDeprecated:
+			toscaExcludes[e] = true
+		default:
+			fmt.Printf("Unknown --tosca-exclude %s, expected %s, %s or %s\n", e,
+				api.MaturityAlpha, api.MaturityBeta, ExcludeDeprecated)
+			os.Exit(1)
+		}
+	}
+}
+
+// IsExcludedDefinition is true for kinds of an api version or deprecation left out by --tosca-exclude
+func IsExcludedDefinition(def *api.Definition) bool {
+	return toscaExcludes[string(def.Maturity())] || (def.Deprecated && toscaExcludes[ExcludeDeprecated])
+}
+
+// IsExcludedField is true for fields of a maturity or deprecation left out by --tosca-exclude
+func IsExcludedField(field *api.Field) bool {
+	return toscaExcludes[string(field.Maturity)] || (field.Deprecated && toscaExcludes[ExcludeDeprecated])
+}
+
+// GetToscaTypeMetadata returns the maturity of the api version of def and whether it is deprecated
+func GetToscaTypeMetadata(def *api.Definition) map[string]string {
+	metadata := map[string]string{
+		"maturity": string(def.Maturity()),
+	}
+	if def.Version != "" {
+		metadata["api_version"] = def.GroupVersion()
+	}
+	if def.Deprecated {
+		metadata["deprecated"] = "true"
+	}
+	return metadata
+}
+
+// GetPropertyStatus returns the TOSCA status of deprecated and alpha or beta fields, empty for supported ones
+func GetPropertyStatus(field *api.Field) string {
+	switch {
+	case field.Deprecated:
+		return PropertyStatusDeprecated
+	case field.Maturity.IsPreRelease():
+		return PropertyStatusExperimental
+	}
+	return ""
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var RenderedManifestsFile = flag.String("rendered-manifests", "-",
+	"File the manifests rendered from a service template are written to, - for stdout.")
+var InputsFile = flag.String("inputs", "", "YAML file with the input values of the service template.")
+
+// Relationship not implying an order between the source and the target
+const ConnectsToRelationship = "tosca.relationships.ConnectsTo"
+
+// Nesting limit of get_property lookups, reached by cyclic references
+const maxResolveDepth = 32
+
+// RenderManifests writes the definitions of the node templates in a service template
+// as Kubernetes manifests, in the order given by their requirements.
+func RenderManifests(args []string) {
+	if len(args) != 1 {
+		fmt.Printf("Expected a single service template file.\n")
+		os.Exit(1)
+	}
+
+	template := &ServiceTemplate{}
+	loadToscaFile(args[0], template)
+
+	inputs := map[string]interface{}{}
+	if *InputsFile != "" {
+		loadToscaFile(*InputsFile, &inputs)
+	}
+
+	r := &ManifestRenderer{
+		Template: template,
+		Tosca:    LoadImportedToscaTypes(args[0], template),
+		Inputs:   inputs,
+	}
+
+	out := []byte{}
+	for _, name := range r.SortNodeTemplates() {
+		definition := r.Resolve(name, template.TopologyTemplate.NodeTemplates[name].Properties[DefinitionProperty], 0)
+		b, err := yaml.Marshal(definition)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, "---\n"...)
+		out = append(out, b...)
+	}
+
+	if *RenderedManifestsFile == "-" {
+		os.Stdout.Write(out)
+		return
+	}
+	if err := ioutil.WriteFile(*RenderedManifestsFile, out, 0644); err != nil {
+		panic(err)
+	}
+}
+
+// LoadImportedToscaTypes loads the types of the imports found next to the service template
+func LoadImportedToscaTypes(fn string, template *ServiceTemplate) *ToscaTypes {
+	tosca := NewToscaTypes()
+	for _, i := range template.Imports {
+		path := filepath.Join(filepath.Dir(fn), i)
+		if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not load import %s: %v\n", i, err)
+			continue
+		}
+		imported := NewToscaTypes()
+		loadToscaFile(path, imported)
+		for name, t := range imported.DataTypes {
+			tosca.DataTypes[name] = t
+		}
+		for name, t := range imported.NodeTypes {
+			tosca.NodeTypes[name] = t
+		}
+		for name, t := range imported.ArtifactTypes {
+			tosca.ArtifactTypes[name] = t
+		}
+		for name, t := range imported.CapabilityTypes {
+			tosca.CapabilityTypes[name] = t
+		}
+		for name, t := range imported.RelationshipTypes {
+			tosca.RelationshipTypes[name] = t
+		}
+		for name, t := range imported.InterfaceTypes {
+			tosca.InterfaceTypes[name] = t
+		}
+	}
+	return tosca
+}
+
+type ManifestRenderer struct {
+	Template *ServiceTemplate
+	Tosca    *ToscaTypes
+	Inputs   map[string]interface{}
+}
+
+// SortNodeTemplates returns the node templates with a definition, every template after
+// the templates it requires. Requirements with a ConnectsTo relationship are ignored.
+func (r *ManifestRenderer) SortNodeTemplates() []string {
+	templates := r.Template.TopologyTemplate.NodeTemplates
+	dependencies := map[string]map[string]bool{}
+	for name, t := range templates {
+		if _, ok := t.Properties[DefinitionProperty]; !ok {
+			continue
+		}
+		dependencies[name] = map[string]bool{}
+	}
+	for name := range dependencies {
+		t := templates[name]
+		for _, requirements := range t.Requirements {
+			for req, assignment := range requirements {
+				if _, ok := dependencies[assignment.Node]; !ok {
+					continue
+				}
+				relationship := assignment.Relationship
+				if relationship == "" {
+					relationship = r.GetRequirementDefinition(t.Type, req).Relationship
+				}
+				if relationship != ConnectsToRelationship {
+					dependencies[name][assignment.Node] = true
+				}
+			}
+		}
+	}
+
+	sorted := []string{}
+	for len(dependencies) > 0 {
+		ready := []string{}
+		for name, deps := range dependencies {
+			if len(deps) == 0 {
+				ready = append(ready, name)
+			}
+		}
+		if len(ready) == 0 {
+			remaining := []string{}
+			for name := range dependencies {
+				remaining = append(remaining, name)
+			}
+			sort.Strings(remaining)
+			panic(fmt.Sprintf("Found cyclic requirements between %s", strings.Join(remaining, ", ")))
+		}
+		sort.Strings(ready)
+		for _, name := range ready {
+			delete(dependencies, name)
+			for _, deps := range dependencies {
+				delete(deps, name)
+			}
+		}
+		sorted = append(sorted, ready...)
+	}
+	return sorted
+}
+
+// GetRequirementDefinition looks up a requirement in the node type and the types it is derived from,
+// reporting cyclic derived_from chains
+func (r *ManifestRenderer) GetRequirementDefinition(node_type, name string) RequirementDefinition {
+	seen := map[string]bool{}
+	for n := node_type; n != ""; n = r.Tosca.NodeTypes[n].DerivedFrom {
+		if seen[n] {
+			reportToscaError(fmt.Sprintf("Found cyclic derived_from of node type %s", n))
+			break
+		}
+		seen[n] = true
+		t, ok := r.Tosca.NodeTypes[n]
+		if !ok {
+			break
+		}
+		for _, requirements := range t.Requirements {
+			if def, ok := requirements[name]; ok {
+				return def
+			}
+		}
+	}
+	return RequirementDefinition{}
+}
+
+// Resolve replaces get_input and get_property functions in the value v of the node template node
+func (r *ManifestRenderer) Resolve(node string, v interface{}, depth int) interface{} {
+	if depth > maxResolveDepth {
+		reportToscaError(fmt.Sprintf("Could not resolve cyclic reference in %s", node))
+		return v
+	}
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		if len(value) == 1 {
+			if arg, ok := value["get_input"]; ok {
+				return r.resolveInput(node, arg, depth)
+			}
+			if arg, ok := value["get_property"]; ok {
+				return r.resolveProperty(node, arg, depth)
+			}
+		}
+		resolved := map[interface{}]interface{}{}
+		for k, item := range value {
+			resolved[k] = r.Resolve(node, item, depth)
+		}
+		return resolved
+	case map[string]interface{}:
+		m := map[interface{}]interface{}{}
+		for k, item := range value {
+			m[k] = item
+		}
+		return r.Resolve(node, m, depth)
+	case []interface{}:
+		resolved := []interface{}{}
+		for _, item := range value {
+			resolved = append(resolved, r.Resolve(node, item, depth))
+		}
+		return resolved
+	}
+	return v
+}
+
+func (r *ManifestRenderer) resolveInput(node string, arg interface{}, depth int) interface{} {
+	if args, ok := arg.([]interface{}); ok && len(args) == 1 {
+		arg = args[0]
+	}
+	name, _ := arg.(string)
+	if value, ok := r.Inputs[name]; ok {
+		return value
+	}
+	input, ok := r.Template.TopologyTemplate.Inputs[name]
+	if !ok || (input.Default.Value == nil && input.Default.ToscaFunction == nil) {
+		reportToscaError(fmt.Sprintf("No value for input %v used in %s", arg, node))
+		return map[interface{}]interface{}{"get_input": arg}
+	}
+	if input.Default.ToscaFunction != nil {
+		return r.Resolve(node, functionToValue(input.Default.ToscaFunction), depth+1)
+	}
+	return r.Resolve(node, input.Default.Value, depth+1)
+}
+
+// resolveProperty resolves [SELF|<node template>, [<requirement>,] <property>, <nested keys or indexes>...]
+func (r *ManifestRenderer) resolveProperty(node string, arg interface{}, depth int) interface{} {
+	unresolved := map[interface{}]interface{}{"get_property": arg}
+	args, _ := arg.([]interface{})
+	if len(args) < 2 {
+		reportToscaError(fmt.Sprintf("Invalid get_property %v in %s", arg, node))
+		return unresolved
+	}
+
+	target, _ := args[0].(string)
+	if target == "SELF" {
+		target = node
+	}
+	template, ok := r.Template.TopologyTemplate.NodeTemplates[target]
+	if !ok {
+		reportToscaError(fmt.Sprintf("Unknown node template %s in get_property of %s", target, node))
+		return unresolved
+	}
+	path := args[1:]
+	if req, ok := path[0].(string); ok && len(path) > 1 {
+		for _, requirements := range template.Requirements {
+			if assignment, ok := requirements[req]; ok {
+				target = assignment.Node
+				template = r.Template.TopologyTemplate.NodeTemplates[target]
+				path = path[1:]
+				break
+			}
+		}
+	}
+
+	property, _ := path[0].(string)
+	value, ok := template.Properties[property]
+	if !ok {
+		reportToscaError(fmt.Sprintf("Node template %s has no property %s used in %s", target, property, node))
+		return unresolved
+	}
+	value = r.Resolve(target, value, depth+1)
+	for _, key := range path[1:] {
+		switch v := value.(type) {
+		case map[interface{}]interface{}:
+			value, ok = v[key]
+		case []interface{}:
+			i, isIndex := key.(int)
+			ok = isIndex && i >= 0 && i < len(v)
+			if ok {
+				value = v[i]
+			}
+		default:
+			ok = false
+		}
+		if !ok {
+			reportToscaError(fmt.Sprintf("Could not find %v in property %s of %s used in %s", path, property, target, node))
+			return unresolved
+		}
+	}
+	return value
+}
+
+func functionToValue(function map[string][]string) interface{} {
+	m := map[interface{}]interface{}{}
+	for name, args := range function {
+		list := []interface{}{}
+		for _, a := range args {
+			list = append(list, a)
+		}
+		m[name] = list
+	}
+	return m
+}
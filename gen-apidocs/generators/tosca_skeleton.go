@@ -0,0 +1,171 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+// Length of the field descriptions in skeleton comments
+const skeletonDescriptionLength = 100
+
+// GenerateToscaSkeletons writes a service template with an example node template
+// for every kind in included_objects.
+func GenerateToscaSkeletons(config *api.Config) {
+	for _, kind := range config.IncludedObjects {
+		def := config.Definitions.ByKind[kind][0]
+		if def.IsWrapper() || IsExcludedDefinition(def) {
+			continue
+		}
+		writeToscaText(strings.ToLower(kind)+"_skeleton.yaml", []byte(BuildToscaSkeleton(def)))
+	}
+}
+
+// BuildToscaSkeleton returns the text of a service template with a node template of def.
+// The definition is the sample from the examples directory if there is one, otherwise required
+// fields are filled with defaults or placeholders and optional fields are commented out.
+func BuildToscaSkeleton(def *api.Definition) string {
+	b := &strings.Builder{}
+	name := strings.ToLower(def.Name) + "_example"
+
+	fmt.Fprintf(b, "tosca_definitions_version: tosca_simple_yaml_1_3\n")
+	fmt.Fprintf(b, "description: Example node template of %s %s\n", def.GroupVersion(), def.Name)
+	fmt.Fprintf(b, "imports:\n- %s\n", ToscaDefinitionsFile)
+	fmt.Fprintf(b, "topology_template:\n")
+	fmt.Fprintf(b, "  inputs:\n    %s:\n      type: string\n", KubeconfigInput)
+	fmt.Fprintf(b, "  node_templates:\n")
+	fmt.Fprintf(b, "    %s:\n      type: %s\n      properties:\n", ClusterNodeTemplate, HostReqNode)
+	fmt.Fprintf(b, "        %s:\n          get_input: %s\n", KubeconfigInput, KubeconfigInput)
+	fmt.Fprintf(b, "    %s:\n      type: %s\n", name, GetNodeTypeName(def.Name))
+	fmt.Fprintf(b, "      properties:\n        %s:\n", DefinitionProperty)
+
+	if sample := strings.TrimSpace(def.Sample.Sample); len(sample) > 0 {
+		fmt.Fprintf(b, "          # sample from the %s examples\n", def.Name)
+		for _, line := range strings.Split(sample, "\n") {
+			fmt.Fprintf(b, "          %s\n", line)
+		}
+	} else {
+		writeSkeletonFields(b, def, 10, map[string]bool{})
+	}
+
+	fmt.Fprintf(b, "      requirements:\n      - host: %s\n", ClusterNodeTemplate)
+	if def.Namespaced {
+		fmt.Fprintf(b, "      # - %s: <node template of the namespace>\n", NamespaceRequirement)
+	}
+	return b.String()
+}
+
+func writeSkeletonFields(b *strings.Builder, def *api.Definition, indent int, parents map[string]bool) {
+	prefix := strings.Repeat(" ", indent)
+	top := len(parents) == 0
+	parents[def.Name] = true
+	defer delete(parents, def.Name)
+
+	for _, field := range def.Fields {
+		switch {
+		case field.Name == "apiVersion" && top:
+			fmt.Fprintf(b, "%sapiVersion: %s\n", prefix, def.GroupVersion())
+			continue
+		case field.Name == "kind" && top:
+			fmt.Fprintf(b, "%skind: %s\n", prefix, def.Name)
+			continue
+		case !field.Access.IsSettable() || IsExcludedField(field):
+			continue
+		}
+
+		if description := getSkeletonDescription(field.Description); len(description) > 0 {
+			fmt.Fprintf(b, "%s# %s\n", prefix, description)
+		}
+		// objects need a name even though it is optional for generated names
+		required := field.Required || (def.Name == "ObjectMeta" && field.Name == "name")
+		if !required {
+			fmt.Fprintf(b, "%s# %s: %s\n", prefix, field.Name, getSkeletonPlaceholder(field))
+			continue
+		}
+
+		if !field.HasComplexType() || field.Definition.IsWrapper() || parents[field.Definition.Name] {
+			fmt.Fprintf(b, "%s%s: %s\n", prefix, field.Name, getSkeletonPlaceholder(field))
+			continue
+		}
+		nested := &strings.Builder{}
+		writeSkeletonFields(nested, field.Definition, indent+2, parents)
+		// objects without required fields only have commented fields
+		empty := true
+		for _, line := range strings.Split(nested.String(), "\n") {
+			if line = strings.TrimSpace(line); len(line) > 0 && !strings.HasPrefix(line, "#") {
+				empty = false
+			}
+		}
+		switch {
+		case IsArray(field.Type) && empty:
+			fmt.Fprintf(b, "%s%s:\n%s- {}\n%s", prefix, field.Name, prefix, nested.String())
+		case IsArray(field.Type):
+			fmt.Fprintf(b, "%s%s:\n%s- %s", prefix, field.Name, prefix, strings.TrimPrefix(nested.String(), prefix+"  "))
+		case empty:
+			fmt.Fprintf(b, "%s%s: {}\n%s", prefix, field.Name, nested.String())
+		default:
+			fmt.Fprintf(b, "%s%s:\n%s", prefix, field.Name, nested.String())
+		}
+	}
+}
+
+// getSkeletonPlaceholder returns the default, the first allowed value or a placeholder of the field type
+func getSkeletonPlaceholder(field *api.Field) string {
+	if IsArray(field.Type) {
+		if field.HasComplexType() && !field.Definition.IsWrapper() {
+			return "[]"
+		}
+		return fmt.Sprintf("[%q]", "<"+field.Name+">")
+	}
+	if field.HasComplexType() && !field.Definition.IsWrapper() {
+		return "{}"
+	}
+
+	c := field.Constraints.AtLeast(toscaMinConfidence)
+	if c.Default != nil {
+		if s, ok := c.Default.(string); ok {
+			return fmt.Sprintf("%q", s)
+		}
+		return fmt.Sprint(c.Default)
+	}
+	if len(c.AllowedValues) > 0 {
+		return fmt.Sprintf("%q", c.AllowedValues[0])
+	}
+
+	switch GetToscaTypeFromSpec(field.Type) {
+	case "integer":
+		if c.Minimum != nil {
+			return fmt.Sprint(*c.Minimum)
+		}
+		return "0"
+	case ToscaFloat:
+		return "0.0"
+	case "boolean":
+		return "false"
+	case ToscaMap:
+		return "{}"
+	}
+	return fmt.Sprintf("%q", "<"+field.Name+">")
+}
+
+// getSkeletonDescription returns the first sentence of a description, shortened to a comment line
+func getSkeletonDescription(description string) string {
+	return TruncateDescription(GetFirstSentence(api.UnescapeAsterisks(description)), skeletonDescriptionLength)
+}
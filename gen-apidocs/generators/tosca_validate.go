@@ -0,0 +1,443 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Types the generated definitions build on without defining them, they come with the
+// SODALITE Kubernetes library and are not validated further
+var ExternalToscaTypes = map[string]bool{
+	DataTypeBase: true,
+	NodeTypeBase: true,
+	HostReqNode:  true,
+}
+
+var ToscaPrimitiveTypes = map[string]bool{
+	"string":                true,
+	"integer":               true,
+	ToscaFloat:              true,
+	"boolean":               true,
+	"timestamp":             true,
+	"version":               true,
+	"range":                 true,
+	"scalar-unit.size":      true,
+	"scalar-unit.time":      true,
+	"scalar-unit.frequency": true,
+	"scalar-unit.bitrate":   true,
+	ToscaMap:                true,
+	ToscaArray:              true,
+}
+
+// IsKnownToscaType is true for primitive, normative and external types
+func IsKnownToscaType(t string) bool {
+	return ToscaPrimitiveTypes[t] || ToscaNormativeTypes[t] || ExternalToscaTypes[t]
+}
+
+// ValidateServiceTemplate checks a service template against the types in its imports
+// and exits with an error if any check fails.
+func ValidateServiceTemplate(args []string) {
+	if len(args) != 1 {
+		fmt.Printf("Expected a single service template file.\n")
+		os.Exit(1)
+	}
+
+	template := &ServiceTemplate{}
+	loadToscaFile(args[0], template)
+
+	tosca := LoadImportedToscaTypes(args[0], template)
+	if len(tosca.NodeTypes) == 0 {
+		fmt.Printf("Warning: No node types found in the imports, using %s\n", ToscaDefinitionsFile)
+		loadToscaFile(filepath.Join(ToscaModuleDir, ToscaDefinitionsFile), tosca)
+	}
+
+	v := &ToscaValidator{Tosca: tosca, Template: template}
+	v.Validate()
+	for _, e := range v.Errors {
+		fmt.Printf("%s\n", e)
+	}
+	if len(v.Errors) > 0 {
+		fmt.Printf("Found %d errors in %s\n", len(v.Errors), args[0])
+		os.Exit(1)
+	}
+	fmt.Printf("%s is valid\n", args[0])
+}
+
+type ToscaValidator struct {
+	Tosca    *ToscaTypes
+	Template *ServiceTemplate
+	Errors   []string
+}
+
+func (v *ToscaValidator) errorf(path, format string, args ...interface{}) {
+	v.Errors = append(v.Errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+// Validate checks the node templates in name order, the errors are collected in Errors
+func (v *ToscaValidator) Validate() {
+	templates := v.Template.TopologyTemplate.NodeTemplates
+	names := []string{}
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.validateNodeTemplate(name, templates[name])
+	}
+}
+
+func (v *ToscaValidator) validateNodeTemplate(name string, t NodeTemplate) {
+	path := "node_templates." + name
+	if IsKnownToscaType(t.Type) {
+		return
+	}
+	if _, ok := v.Tosca.NodeTypes[t.Type]; !ok {
+		v.errorf(path+".type", "unknown node type %s", t.Type)
+		return
+	}
+
+	properties := map[string]PropertyDefinition{}
+	requirements := map[string]bool{}
+	if !v.checkDerivedFrom(path+".type", t.Type, func(n string) (string, bool) {
+		nt, ok := v.Tosca.NodeTypes[n]
+		for p, def := range nt.Properties {
+			if _, ok := properties[p]; !ok {
+				properties[p] = def
+			}
+		}
+		for _, reqs := range nt.Requirements {
+			for r := range reqs {
+				requirements[r] = true
+			}
+		}
+		return nt.DerivedFrom, ok
+	}) {
+		return
+	}
+
+	v.validateProperties(path+".properties", properties, toInterfaceMap(t.Properties))
+
+	for _, reqs := range t.Requirements {
+		for r, assignment := range reqs {
+			if !requirements[r] {
+				v.errorf(path+".requirements."+r, "unknown requirement of %s", t.Type)
+			}
+			if _, ok := v.Template.TopologyTemplate.NodeTemplates[assignment.Node]; !ok {
+				v.errorf(path+".requirements."+r, "unknown node template %s", assignment.Node)
+			}
+		}
+	}
+}
+
+// checkDerivedFrom follows the derived_from chain of t until a known type is reached,
+// parent returns the type a type is derived from and false for undefined types
+func (v *ToscaValidator) checkDerivedFrom(path, t string, parent func(string) (string, bool)) bool {
+	seen := map[string]bool{}
+	for !IsKnownToscaType(t) {
+		if seen[t] {
+			v.errorf(path, "cyclic derived_from chain at %s", t)
+			return false
+		}
+		seen[t] = true
+		next, ok := parent(t)
+		if !ok {
+			v.errorf(path, "unknown type %s", t)
+			return false
+		}
+		if next == "" {
+			return true
+		}
+		t = next
+	}
+	return true
+}
+
+func (v *ToscaValidator) validateProperties(path string, definitions map[string]PropertyDefinition, values map[interface{}]interface{}) {
+	keys := map[string]interface{}{}
+	names := []string{}
+	for name, value := range values {
+		keys[fmt.Sprint(name)] = value
+		names = append(names, fmt.Sprint(name))
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		def, ok := definitions[key]
+		if !ok {
+			v.errorf(path+"."+key, "unknown property")
+			continue
+		}
+		v.validateValue(path+"."+key, def, keys[key])
+	}
+
+	names = []string{}
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := definitions[name]
+		// properties are required unless stated otherwise
+		required := def.Required == nil || *def.Required
+		if _, ok := values[name]; !ok && required && def.Default.Value == nil && def.Default.ToscaFunction == nil {
+			v.errorf(path+"."+name, "missing required property")
+		}
+	}
+}
+
+func (v *ToscaValidator) validateValue(path string, def PropertyDefinition, value interface{}) {
+	if v.isFunction(path, value) {
+		return
+	}
+	if def.Type == ToscaMap && def.EntrySchema.Type != "" && !ToscaPrimitiveTypes[v.baseType(def.EntrySchema.Type)] {
+		// complex fields are maps with the data type as entry schema, the value is the data type itself
+		v.validateType(path, def.EntrySchema.Type, value)
+	} else if def.Type == ToscaMap && def.EntrySchema.Type != "" {
+		if m, ok := value.(map[interface{}]interface{}); ok {
+			for k, item := range m {
+				v.validateType(fmt.Sprintf("%s.%v", path, k), def.EntrySchema.Type, item)
+			}
+		} else {
+			v.errorf(path, "expected map, found %s", describeValue(value))
+		}
+	} else if def.Type == ToscaArray && def.EntrySchema.Type != "" {
+		if l, ok := value.([]interface{}); ok {
+			for i, item := range l {
+				v.validateType(fmt.Sprintf("%s[%d]", path, i), def.EntrySchema.Type, item)
+			}
+		} else {
+			v.errorf(path, "expected list, found %s", describeValue(value))
+		}
+	} else {
+		v.validateType(path, def.Type, value)
+	}
+	v.validateConstraints(path, def.Constraints, value)
+}
+
+// baseType returns the primitive type a data type is derived from, or the data type itself
+func (v *ToscaValidator) baseType(t string) string {
+	for i := 0; i < 32; i++ {
+		dt, ok := v.Tosca.DataTypes[t]
+		if !ok || dt.DerivedFrom == "" || dt.DerivedFrom == DataTypeBase {
+			return t
+		}
+		t = dt.DerivedFrom
+	}
+	return t
+}
+
+func (v *ToscaValidator) validateType(path, t string, value interface{}) {
+	if v.isFunction(path, value) {
+		return
+	}
+	base := v.baseType(t)
+	if ToscaPrimitiveTypes[base] {
+		v.validatePrimitive(path, base, value)
+		return
+	}
+	if IsKnownToscaType(base) {
+		return
+	}
+	if _, ok := v.Tosca.DataTypes[base]; !ok {
+		v.errorf(path, "unknown data type %s", t)
+		return
+	}
+
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		v.errorf(path, "expected %s, found %s", t, describeValue(value))
+		return
+	}
+	properties := map[string]PropertyDefinition{}
+	v.checkDerivedFrom(path, base, func(n string) (string, bool) {
+		dt, ok := v.Tosca.DataTypes[n]
+		for p, def := range dt.Properties {
+			if _, ok := properties[p]; !ok {
+				properties[p] = def
+			}
+		}
+		return dt.DerivedFrom, ok
+	})
+	v.validateProperties(path, properties, m)
+}
+
+func (v *ToscaValidator) validatePrimitive(path, t string, value interface{}) {
+	ok := true
+	switch t {
+	case "string":
+		// IntOrString fields are generated as strings
+		switch value.(type) {
+		case string, int:
+		default:
+			ok = false
+		}
+	case "integer":
+		_, ok = value.(int)
+	case ToscaFloat:
+		switch value.(type) {
+		case float64, int:
+		default:
+			ok = false
+		}
+	case "boolean":
+		_, ok = value.(bool)
+	case ToscaMap:
+		_, ok = value.(map[interface{}]interface{})
+	case ToscaArray:
+		_, ok = value.([]interface{})
+	}
+	if !ok {
+		v.errorf(path, "expected %s, found %s", t, describeValue(value))
+	}
+}
+
+func (v *ToscaValidator) validateConstraints(path string, constraints []map[string]interface{}, value interface{}) {
+	for _, constraint := range constraints {
+		for operator, arg := range constraint {
+			if ok, known := checkConstraint(operator, arg, value); !known {
+				v.errorf(path, "unsupported constraint %s", operator)
+			} else if !ok {
+				v.errorf(path, "%s violates %s %v", describeValue(value), operator, arg)
+			}
+		}
+	}
+}
+
+// checkConstraint returns whether value satisfies the constraint and whether the operator is supported
+func checkConstraint(operator string, arg, value interface{}) (bool, bool) {
+	switch operator {
+	case "equal":
+		return fmt.Sprint(value) == fmt.Sprint(arg), true
+	case "valid_values":
+		values, _ := arg.([]interface{})
+		for _, a := range values {
+			if fmt.Sprint(value) == fmt.Sprint(a) {
+				return true, true
+			}
+		}
+		return false, true
+	case "greater_than", "greater_or_equal", "less_than", "less_or_equal":
+		a, ok1 := toFloat(arg)
+		x, ok2 := toFloat(value)
+		if !ok1 || !ok2 {
+			return false, true
+		}
+		switch operator {
+		case "greater_than":
+			return x > a, true
+		case "greater_or_equal":
+			return x >= a, true
+		case "less_than":
+			return x < a, true
+		}
+		return x <= a, true
+	case "in_range":
+		bounds, _ := arg.([]interface{})
+		if len(bounds) != 2 {
+			return false, true
+		}
+		ok1, _ := checkConstraint("greater_or_equal", bounds[0], value)
+		ok2, _ := checkConstraint("less_or_equal", bounds[1], value)
+		return ok1 && ok2, true
+	case "length", "min_length", "max_length":
+		n, ok := arg.(int)
+		length := -1
+		switch x := value.(type) {
+		case string:
+			length = len(x)
+		case []interface{}:
+			length = len(x)
+		case map[interface{}]interface{}:
+			length = len(x)
+		}
+		if !ok || length < 0 {
+			return false, true
+		}
+		switch operator {
+		case "length":
+			return length == n, true
+		case "min_length":
+			return length >= n, true
+		}
+		return length <= n, true
+	case "pattern":
+		s, ok := value.(string)
+		p, _ := arg.(string)
+		matched, err := regexp.MatchString("^(?:"+p+")$", s)
+		return ok && err == nil && matched, true
+	}
+	return false, false
+}
+
+// isFunction is true for values computed by the orchestrator, get_input must refer to a declared input
+func (v *ToscaValidator) isFunction(path string, value interface{}) bool {
+	m, ok := value.(map[interface{}]interface{})
+	if !ok || len(m) != 1 {
+		return false
+	}
+	for k, arg := range m {
+		name := fmt.Sprint(k)
+		if name == "get_input" {
+			input := arg
+			if args, ok := arg.([]interface{}); ok && len(args) == 1 {
+				input = args[0]
+			}
+			if _, ok := v.Template.TopologyTemplate.Inputs[fmt.Sprint(input)]; !ok {
+				v.errorf(path, "unknown input %v", input)
+			}
+		}
+		return strings.HasPrefix(name, "get_") || name == "concat" || name == "join" || name == "token"
+	}
+	return false
+}
+
+func toInterfaceMap(m map[string]interface{}) map[interface{}]interface{} {
+	result := map[interface{}]interface{}{}
+	for k, item := range m {
+		result[k] = item
+	}
+	return result
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch x := value.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case float64:
+		return x, true
+	}
+	return 0, false
+}
+
+func describeValue(value interface{}) string {
+	switch value.(type) {
+	case map[interface{}]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T %v", value, value)
+}
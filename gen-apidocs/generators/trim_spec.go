@@ -0,0 +1,140 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/spec"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var TrimmedSpecFile = flag.String("trimmed-spec", "",
+	"File the trimmed open-api spec is written to, defaults to build/swagger.json in the work directory.")
+
+// TrimSpec writes an open-api spec with only the given kinds, or the included_objects if none
+// are given, their operations and the definitions these refer to.
+func TrimSpec(kinds []string) {
+	config := api.NewConfig()
+	if len(kinds) == 0 {
+		kinds = config.IncludedObjects
+	}
+	roots := []*api.Definition{}
+	for _, kind := range kinds {
+		defs, ok := config.Definitions.ByKind[kind]
+		if !ok {
+			fmt.Printf("Unknown kind %s.\n", kind)
+			os.Exit(1)
+		}
+		roots = append(roots, defs[0])
+	}
+
+	fn := *TrimmedSpecFile
+	if fn == "" {
+		fn = filepath.Join(api.BuildDir, "swagger.json")
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), os.ModePerm); err != nil {
+		panic(err)
+	}
+	trimmed := BuildTrimmedSpec(api.LoadOpenApiSpec(), config.Definitions.Closure(roots), config.GetOperationsOf(roots))
+	writeJSONFile(fn, trimmed)
+	fmt.Printf("Wrote %d paths and %d definitions to %s\n", len(trimmed.Paths.Paths), len(trimmed.Definitions), fn)
+}
+
+// BuildTrimmedSpec returns a spec with the paths of ops and the definitions in closure, together with
+// the definitions the api model does not resolve, like IntOrString, and those of the operations.
+func BuildTrimmedSpec(specs []*loads.Document, closure []*api.Definition, ops []*api.Operation) *spec.Swagger {
+	all := spec.Definitions{}
+	parameters := map[string]spec.Parameter{}
+	trimmed := &spec.Swagger{}
+	for i, doc := range specs {
+		if i == 0 {
+			trimmed.SwaggerProps = doc.Spec().SwaggerProps
+		}
+		for name, schema := range doc.Spec().Definitions {
+			all[name] = schema
+		}
+		for name, p := range doc.Spec().Parameters {
+			parameters[name] = p
+		}
+	}
+
+	trimmed.Paths = &spec.Paths{Paths: map[string]spec.PathItem{}}
+	for _, o := range ops {
+		source := getPathItem(specs, o.Path)
+		item, ok := trimmed.Paths.Paths[o.Path]
+		if !ok {
+			item.PathItemProps.Parameters = source.PathItemProps.Parameters
+			item.Extensions = source.Extensions
+		}
+		switch o.HttpMethod {
+		case "GET":
+			item.Get = source.Get
+		case "DELETE":
+			item.Delete = source.Delete
+		case "PATCH":
+			item.Patch = source.Patch
+		case "PUT":
+			item.Put = source.Put
+		case "POST":
+			item.Post = source.Post
+		case "HEAD":
+			item.Head = source.Head
+		}
+		trimmed.Paths.Paths[o.Path] = item
+	}
+
+	trimmed.Definitions = spec.Definitions{}
+	queue := []string{}
+	for _, d := range closure {
+		queue = append(queue, d.OpenApiName)
+	}
+	queue = collectRefs(toJSONValue(trimmed.Paths), definitionsRefPrefix, queue)
+	trimmed.Parameters = map[string]spec.Parameter{}
+	for _, name := range collectRefs(toJSONValue(trimmed.Paths), parametersRefPrefix, []string{}) {
+		trimmed.Parameters[name] = parameters[name]
+	}
+	queue = collectRefs(toJSONValue(trimmed.Parameters), definitionsRefPrefix, queue)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if _, ok := trimmed.Definitions[name]; ok {
+			continue
+		}
+		schema, ok := all[name]
+		if !ok {
+			panic(fmt.Sprintf("Could not find definition %s in the open-api spec", name))
+		}
+		trimmed.Definitions[name] = schema
+		queue = collectRefs(toJSONValue(schema), definitionsRefPrefix, queue)
+	}
+	return trimmed
+}
+
+func getPathItem(specs []*loads.Document, path string) spec.PathItem {
+	for _, doc := range specs {
+		if item, ok := doc.Spec().Paths.Paths[path]; ok {
+			return item
+		}
+	}
+	panic(fmt.Sprintf("Could not find path %s in the open-api spec", path))
+}
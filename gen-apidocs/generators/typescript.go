@@ -0,0 +1,217 @@
+/*
+Copyright 2020 SODALITE EU Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generators
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/kmlTE/reference-docs/gen-apidocs/generators/api"
+)
+
+var TypeScriptFile = flag.String("typescript-file", "",
+	"File the TypeScript definitions are written to, defaults to build/typescript/kubernetes-<release>.d.ts in the work directory.")
+var TypeScriptMinConfidence = flag.String("typescript-min-confidence", "medium",
+	"Lowest confidence (low, medium or high, none disables it) of allowed values mined from descriptions to write as string literal unions.")
+
+// TypeScript types of definitions the api model does not resolve
+var typeScriptUnresolvedTypes = map[string]string{
+	"IntOrString":  "number | string",
+	"RawExtension": "{ [key: string]: any }",
+}
+
+var typeScriptIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// GenerateTypeScript writes a TypeScript declaration module with an interface for every definition,
+// in namespaces named <group>.<version>.
+func GenerateTypeScript(config *api.Config) {
+	fn := *TypeScriptFile
+	if fn == "" {
+		fn = filepath.Join(api.BuildDir, "typescript", "kubernetes-"+config.SpecVersion+".d.ts")
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), os.ModePerm); err != nil {
+		panic(err)
+	}
+	w := NewTypeScriptWriter(config)
+	if err := ioutil.WriteFile(fn, []byte(w.Write()), 0644); err != nil {
+		panic(err)
+	}
+	fmt.Printf("Wrote TypeScript definitions to %s\n", fn)
+}
+
+type TypeScriptWriter struct {
+	Config        *api.Config
+	MinConfidence api.Confidence
+	b             *strings.Builder
+}
+
+func NewTypeScriptWriter(config *api.Config) *TypeScriptWriter {
+	min_confidence, err := api.ParseConfidence(*TypeScriptMinConfidence)
+	if err != nil {
+		panic(err)
+	}
+	return &TypeScriptWriter{Config: config, MinConfidence: min_confidence, b: &strings.Builder{}}
+}
+
+// Write returns the declarations of all definitions grouped by namespace
+func (w *TypeScriptWriter) Write() string {
+	namespaces := map[string]api.SortDefinitionsByName{}
+	for _, d := range w.Config.Definitions.All {
+		ns := GetTypeScriptNamespace(d)
+		namespaces[ns] = append(namespaces[ns], d)
+	}
+	names := []string{}
+	for ns := range namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w.b, "// TypeScript definitions of the Kubernetes %s API, generated by gen-apidocs.\n", w.Config.SpecVersion)
+	for _, ns := range names {
+		defs := namespaces[ns]
+		sort.Sort(defs)
+		fmt.Fprintf(w.b, "\nexport namespace %s {\n", ns)
+		for i, d := range defs {
+			if i > 0 {
+				fmt.Fprintf(w.b, "\n")
+			}
+			w.writeDefinition(d)
+		}
+		fmt.Fprintf(w.b, "}\n")
+	}
+	return w.b.String()
+}
+
+func (w *TypeScriptWriter) writeDefinition(d *api.Definition) {
+	w.writeDocComment("  ", d.RawDescription, d.Deprecated)
+	if d.IsWrapper() {
+		fmt.Fprintf(w.b, "  export type %s = %s;\n", d.Name, w.getPrimitiveType(d.Type))
+		return
+	}
+
+	group, version, kind, isKind := GetGroupVersionKind(d)
+	fmt.Fprintf(w.b, "  export interface %s {\n", d.Name)
+	fields := append(api.Fields{}, d.Fields...)
+	sort.Sort(fields)
+	for _, field := range fields {
+		w.writeDocComment("    ", field.Description, field.Deprecated)
+		name := field.Name
+		if !typeScriptIdentifier.MatchString(name) {
+			name = fmt.Sprintf("%q", name)
+		}
+		optional := "?"
+		if field.SchemaRequired {
+			optional = ""
+		}
+		t := w.getFieldType(d, field)
+		switch {
+		case isKind && field.Name == "apiVersion" && group == "":
+			t = fmt.Sprintf("%q", version)
+		case isKind && field.Name == "apiVersion":
+			t = fmt.Sprintf("%q", group+"/"+version)
+		case isKind && field.Name == "kind":
+			t = fmt.Sprintf("%q", kind)
+		}
+		fmt.Fprintf(w.b, "    %s%s: %s;\n", name, optional, t)
+	}
+	fmt.Fprintf(w.b, "  }\n")
+}
+
+// writeDocComment writes a description as a JSDoc comment
+func (w *TypeScriptWriter) writeDocComment(indent, description string, deprecated bool) {
+	description = strings.TrimSpace(api.UnescapeAsterisks(description))
+	if description == "" && !deprecated {
+		return
+	}
+	description = strings.Replace(description, "*/", "*\\/", -1)
+	fmt.Fprintf(w.b, "%s/**\n", indent)
+	for _, line := range strings.Split(description, "\n") {
+		fmt.Fprintf(w.b, "%s *%s\n", indent, strings.TrimRight(" "+line, " "))
+	}
+	if deprecated {
+		fmt.Fprintf(w.b, "%s * @deprecated\n", indent)
+	}
+	fmt.Fprintf(w.b, "%s */\n", indent)
+}
+
+// getFieldType returns a union of the allowed values of string fields if they are known,
+// otherwise the type of the field schema
+func (w *TypeScriptWriter) getFieldType(d *api.Definition, field *api.Field) string {
+	c := field.Constraints.AtLeast(w.MinConfidence)
+	if field.Type == "string" && len(c.AllowedValues) > 0 {
+		values := []string{}
+		for _, v := range c.AllowedValues {
+			values = append(values, fmt.Sprintf("%q", v))
+		}
+		return strings.Join(values, " | ")
+	}
+	return w.getSchemaType(d.Schema().Properties[field.Name])
+}
+
+func (w *TypeScriptWriter) getSchemaType(s spec.Schema) string {
+	if api.IsDefinition(s) {
+		if def, ok := w.Config.Definitions.GetForSchema(s); ok {
+			return GetTypeScriptNamespace(def) + "." + def.Name
+		}
+		_, _, kind := api.GetDefinitionVersionKind(s)
+		if t, ok := typeScriptUnresolvedTypes[kind]; ok {
+			return t
+		}
+		return "any"
+	}
+	if api.IsArray(s) && s.Items != nil && s.Items.Schema != nil {
+		t := w.getSchemaType(*s.Items.Schema)
+		if strings.Contains(t, " ") {
+			return "Array<" + t + ">"
+		}
+		return t + "[]"
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return "{ [key: string]: " + w.getSchemaType(*s.AdditionalProperties.Schema) + " }"
+	}
+	if len(s.Type) > 0 {
+		return w.getPrimitiveType(s.Type[0])
+	}
+	return "any"
+}
+
+func (w *TypeScriptWriter) getPrimitiveType(t string) string {
+	switch t {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "object":
+		return "{ [key: string]: any }"
+	}
+	return "any"
+}
+
+// GetTypeScriptNamespace returns the namespace of a definition, e.g. apps.v1
+func GetTypeScriptNamespace(d *api.Definition) string {
+	return strings.Replace(d.Group.String(), "-", "_", -1) + "." + d.Version.String()
+}
@@ -16,7 +16,9 @@ limitations under the License.
 package generators
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -44,23 +46,116 @@ type DocWriter interface {
 	Finalize()
 }
 
-func GenerateFiles() {
-	// Load the yaml config
+var Outputs = flag.String("output", OutputTosca,
+	"Comma separated outputs generated from the loaded API specs: html, html-pages, markdown, tosca, tosca-skeleton, json-schema, typescript or model.")
+
+// Outputs that only need the loaded config
+const OutputHTML = "html"
+const OutputHTMLPages = "html-pages"
+const OutputMarkdown = "markdown"
+const OutputTosca = "tosca"
+const OutputToscaSkeleton = "tosca-skeleton"
+const OutputJSONSchema = "json-schema"
+const OutputTypeScript = "typescript"
+const OutputModel = "model"
+
+var outputGenerators = map[string]func(*api.Config){
+	OutputHTML:          GenerateFiles,
+	OutputHTMLPages:     GenerateHTMLPages,
+	OutputMarkdown:      GenerateMarkdown,
+	OutputTosca:         GenerateToscaYAML,
+	OutputToscaSkeleton: GenerateToscaSkeletons,
+	OutputJSONSchema:    GenerateJSONSchemas,
+	OutputTypeScript:    GenerateTypeScript,
+	OutputModel:         GenerateModel,
+}
+
+// IsOutput is true for the names of outputs generated by GenerateOutputs
+func IsOutput(name string) bool {
+	_, ok := outputGenerators[name]
+	return ok
+}
+
+// GetOutputs returns the outputs listed in --output
+func GetOutputs() []string {
+	outputs := []string{}
+	for _, o := range strings.Split(*Outputs, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			outputs = append(outputs, o)
+		}
+	}
+	return outputs
+}
+
+// ParseFlags checks the flags the outputs and commands read and parses them once,
+// exiting on invalid values before anything is loaded
+func ParseFlags() {
+	var err error
+	if toscaMinConfidence, err = api.ParseConfidence(*ToscaMinConfidence); err != nil {
+		fmt.Printf("Invalid --tosca-min-confidence: %v\n", err)
+		os.Exit(1)
+	}
+	if *FieldSort != FieldSortAlphabetical && *FieldSort != FieldSortRequiredFirst {
+		fmt.Printf("Unknown --field-sort %s, expected %s or %s\n", *FieldSort, FieldSortAlphabetical, FieldSortRequiredFirst)
+		os.Exit(1)
+	}
+	checkDescriptionFlags()
+	parseToscaExcludes()
+}
+
+// GenerateOutputs loads the API specs once and generates the given outputs from them
+func GenerateOutputs(outputs []string) {
+	for _, o := range outputs {
+		if !IsOutput(o) {
+			fmt.Printf("Unknown output %s, expected %s, %s, %s, %s, %s, %s, %s or %s\n", o,
+				OutputHTML, OutputHTMLPages, OutputMarkdown, OutputTosca, OutputToscaSkeleton, OutputJSONSchema, OutputTypeScript, OutputModel)
+			os.Exit(1)
+		}
+	}
 	config := api.NewConfig()
+	for _, o := range outputs {
+		outputGenerators[o](config)
+	}
+}
+
+// GenerateFiles writes the HTML reference
+func GenerateFiles(config *api.Config) {
+	PrintInfo(config)
+	ensureIncludeDir()
+	config.LoadHistory()
+	writeDocs(config, NewHTMLWriter(config, getCopyright(), getDocsTitle()))
+}
+
+// GenerateHTMLPages writes the HTML reference as a page per resource and definition
+func GenerateHTMLPages(config *api.Config) {
 	PrintInfo(config)
 	ensureIncludeDir()
+	config.LoadHistory()
+	writeDocs(config, NewHTMLPagesWriter(config, getCopyright(), getDocsTitle()))
+}
 
+// GenerateMarkdown writes the reference as Markdown pages for Hugo
+func GenerateMarkdown(config *api.Config) {
+	PrintInfo(config)
+	writeDocs(config, NewMarkdownWriter(config, getDocsTitle()))
+}
+
+func getCopyright() string {
 	copyright_tmpl := "<a href=\"https://github.com/kubernetes/kubernetes\">Copyright 2016-%s The Kubernetes Authors.</a>"
 	now := time.Now().Format("2006")
-	copyright := fmt.Sprintf(copyright_tmpl, now)
-	var title string
+	return fmt.Sprintf(copyright_tmpl, now)
+}
+
+func getDocsTitle() string {
 	if !*api.BuildOps {
-		title = "Kubernetes Resource Reference Docs"
-	} else {
-		title = "Kubernetes API Reference Docs"
+		return "Kubernetes Resource Reference Docs"
 	}
+	return "Kubernetes API Reference Docs"
+}
 
-	writer := NewHTMLWriter(config, copyright, title)
+// writeDocs writes the overview, the resources by category, the other definitions and the old versions
+func writeDocs(config *api.Config, writer DocWriter) {
+	CheckResourceLinks(config)
 	writer.WriteOverview()
 
 	// Write API groups
@@ -167,49 +262,83 @@ func writeStaticFile(title, location, defaultContent string) {
 	file.Close()
 }
 
-func GenerateToscaYAML() {
-	// Load the yaml config
-	config := api.NewConfig()
+func GenerateToscaYAML(config *api.Config) {
 	//PrintToscaInfo(config)
 
-	tosca := &ToscaTypes{}
-	tosca.Version = "tosca_simple_yaml_1_3"
-	tosca.DataTypes = map[string]DataType{}
-	tosca.NodeTypes = map[string]NodeType{}
-
+	tosca := NewToscaTypes()
+	tosca.Imports = GetToscaImports()
 	BuildToscaTypesFromDefinitions(config, tosca)
 
 	//DumpToscaYAML(tosca)
 
+	CheckGeneratedToscaTypes(tosca)
 	createToscaYAML(tosca)
+
+	for name, template := range BuildCompositeTemplates(config) {
+		writeToscaFile(name, template)
+	}
+}
+
+func NewToscaTypes() *ToscaTypes {
+	tosca := &ToscaTypes{}
+	tosca.Version = "tosca_simple_yaml_1_3"
+	tosca.ArtifactTypes = map[string]ArtifactType{}
+	tosca.DataTypes = map[string]DataType{}
+	tosca.NodeTypes = map[string]NodeType{}
+	tosca.CapabilityTypes = map[string]EntityType{}
+	tosca.RelationshipTypes = map[string]EntityType{}
+	tosca.InterfaceTypes = map[string]EntityType{}
+	return tosca
 }
 
+// Directory and file name of the generated TOSCA module
+const ToscaModuleDir = "/tmp/kubernetes"
+const ToscaDefinitionsFile = "kubernetes_definitions.yaml"
+
 func createToscaYAML(tosca *ToscaTypes) {
-	t, err := yaml.Marshal(&tosca)
-    if err != nil {
-        panic(err)
-    }
+	writeToscaFile(ToscaDefinitionsFile, tosca)
+}
 
-    module_dir := "/tmp/kubernetes"
-    yaml_name := "kubernetes_definitions.yaml"
-    fn := filepath.Join(module_dir, yaml_name)
+// writeToscaFile writes v as YAML to the file yaml_name in the TOSCA module directory
+func writeToscaFile(yaml_name string, v interface{}) {
+	t, err := yaml.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	writeToscaText(yaml_name, t)
+}
+
+// writeToscaText writes t to the file yaml_name in the TOSCA module directory
+func writeToscaText(yaml_name string, t []byte) {
+	fn := filepath.Join(ToscaModuleDir, yaml_name)
 
-    _, err = os.Stat(module_dir)
-    if os.IsNotExist(err) {
-		os.Mkdir(module_dir, os.FileMode(0700))
+	_, err := os.Stat(ToscaModuleDir)
+	if os.IsNotExist(err) {
+		os.Mkdir(ToscaModuleDir, os.FileMode(0700))
 	}
 
 	f, err := os.Create(fn)
-    if err != nil {
-        panic(err)
-    }
+	if err != nil {
+		panic(err)
+	}
 
-    defer f.Close()
+	defer f.Close()
 
-    _, err = f.Write(t)
-    if err != nil {
-        panic(err)
-    }
+	_, err = f.Write(t)
+	if err != nil {
+		panic(err)
+	}
 
-    f.Close()
-}
\ No newline at end of file
+	f.Close()
+}
+
+// loadToscaFile reads the YAML file fn into v
+func loadToscaFile(fn string, v interface{}) {
+	b, err := ioutil.ReadFile(fn)
+	if err != nil {
+		panic(err)
+	}
+	if err := yaml.Unmarshal(b, v); err != nil {
+		panic(fmt.Sprintf("Could not parse %s: %v", fn, err))
+	}
+}
@@ -18,11 +18,34 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
 
 	"github.com/kmlTE/reference-docs/gen-apidocs/generators"
 )
 
 func main() {
 	flag.Parse()
-	generators.GenerateToscaYAML()
+	generators.ParseFlags()
+	switch command := flag.Arg(0); {
+	case command == "":
+		generators.GenerateOutputs(generators.GetOutputs())
+	case generators.IsOutput(command):
+		generators.GenerateOutputs([]string{command})
+	case command == "convert-manifests":
+		generators.ConvertManifests(flag.Args()[1:])
+	case command == "render-manifests":
+		generators.RenderManifests(flag.Args()[1:])
+	case command == "tosca-validate":
+		generators.ValidateServiceTemplate(flag.Args()[1:])
+	case command == "trim-spec":
+		generators.TrimSpec(flag.Args()[1:])
+	case command == "graph":
+		generators.GenerateGraph(flag.Args()[1:])
+	case command == "changelog":
+		generators.GenerateChangelog(flag.Args()[1:])
+	default:
+		fmt.Printf("Unknown command %s, expected html, html-pages, markdown, tosca, tosca-skeleton, json-schema, typescript, model, convert-manifests, render-manifests, tosca-validate, trim-spec, graph or changelog\n", command)
+		os.Exit(1)
+	}
 }